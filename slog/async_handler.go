@@ -0,0 +1,168 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// An OverflowPolicy says what an [AsyncHandler] does with a record when its
+// buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Handle wait until there is room in the buffer.
+	// No records are lost, but a slow inner handler slows the callers.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the record being handled.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest record in the buffer to make
+	// room for the one being handled.
+	OverflowDropOldest
+)
+
+// errAsyncClosed is returned by the Handle method of a closed AsyncHandler.
+var errAsyncClosed = errors.New("slog: AsyncHandler is closed")
+
+// AsyncHandler is a Handler that passes records to another Handler on a
+// background goroutine, so that callers do not wait for slow writers.
+// Records are buffered until the goroutine handles them, in order; when the
+// buffer is full, the handler's OverflowPolicy applies.
+//
+// Call Close to handle the buffered records and stop the goroutine, as when
+// the program exits; records still in the buffer are otherwise lost.
+// An AsyncHandler and the handlers derived from it with With and WithGroup
+// share their buffer and goroutine, and are all closed by Close.
+type AsyncHandler struct {
+	inner Handler
+	q     *asyncQueue
+}
+
+type asyncQueue struct {
+	ch      chan asyncRecord
+	policy  OverflowPolicy
+	done    chan struct{} // closed when the goroutine exits
+	dropped atomic.Int64
+
+	mu     sync.RWMutex // held for writing to close ch
+	closed bool
+
+	err error // first error from an inner handler; read after done
+}
+
+// An asyncRecord is a record and the handler to pass it to.
+type asyncRecord struct {
+	h Handler
+	r Record
+}
+
+// NewAsyncHandler creates an AsyncHandler that passes records to inner,
+// buffering up to size of them, and that applies policy when the buffer is
+// full. It starts the goroutine that calls inner.
+func NewAsyncHandler(inner Handler, size int, policy OverflowPolicy) *AsyncHandler {
+	if size < 1 {
+		size = 1
+	}
+	q := &asyncQueue{
+		ch:     make(chan asyncRecord, size),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	go q.run()
+	return &AsyncHandler{inner: inner, q: q}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *AsyncHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new AsyncHandler whose inner handler has the given
+// attributes. The new handler shares its buffer with h.
+func (h *AsyncHandler) With(attrs []Attr) Handler {
+	return &AsyncHandler{inner: h.inner.With(attrs), q: h.q}
+}
+
+// WithGroup returns a new AsyncHandler whose inner handler has the given
+// group. The new handler shares its buffer with h.
+func (h *AsyncHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &AsyncHandler{inner: h.inner.WithGroup(name), q: h.q}
+}
+
+// Handle adds a copy of r, which shares no state with it, to the buffer.
+// It returns an error if h has been closed, and otherwise nil: errors from
+// the inner handler are returned by Close.
+func (h *AsyncHandler) Handle(r Record) error {
+	return h.q.add(asyncRecord{h.inner, r.Clone()})
+}
+
+// Close handles the records in the buffer, and then stops the goroutine.
+// Later calls to Handle fail. Close returns the first error returned by the
+// inner handler, if any. Calling Close more than once has no further
+// effect.
+func (h *AsyncHandler) Close() error {
+	q := h.q
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+	q.mu.Unlock()
+	<-q.done
+	return q.err
+}
+
+// Dropped returns the number of records discarded because the buffer was
+// full.
+func (h *AsyncHandler) Dropped() int64 {
+	return h.q.dropped.Load()
+}
+
+func (q *asyncQueue) add(ar asyncRecord) error {
+	// Hold the lock for reading, so that ch is not closed during the send.
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return errAsyncClosed
+	}
+	switch q.policy {
+	case OverflowDropNewest:
+		select {
+		case q.ch <- ar:
+		default:
+			q.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case q.ch <- ar:
+				return nil
+			default:
+			}
+			select {
+			case <-q.ch:
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		q.ch <- ar
+	}
+	return nil
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for ar := range q.ch {
+		if err := ar.h.Handle(ar.r); err != nil && q.err == nil {
+			q.err = err
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncHandlerClose(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewAsyncHandler(HandlerOptions{}.NewTextHandler(&buf), 100, OverflowBlock)
+	l := New(h).With("a", 1).WithGroup("g")
+	for i := 0; i < 3; i++ {
+		l.LogAt(time.Time{}, InfoLevel, "m", "i", i)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "level=INFO msg=m a=1 g.i=0\nlevel=INFO msg=m a=1 g.i=1\nlevel=INFO msg=m a=1 g.i=2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != errAsyncClosed {
+		t.Errorf("Handle after Close: got %v, want %v", err, errAsyncClosed)
+	}
+}
+
+// gatedHandler records the messages it handles, after waiting for gate to
+// be closed. It signals started when it first handles a record.
+type gatedHandler struct {
+	discardHandler
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+	msgs    []string
+}
+
+func (h *gatedHandler) Handle(r Record) error {
+	h.once.Do(func() { close(h.started) })
+	<-h.gate
+	h.msgs = append(h.msgs, r.Message())
+	return nil
+}
+
+func TestAsyncHandlerOverflow(t *testing.T) {
+	for _, test := range []struct {
+		policy OverflowPolicy
+		want   string
+	}{
+		{OverflowDropNewest, "0 1 2"},
+		{OverflowDropOldest, "0 4 5"},
+	} {
+		inner := &gatedHandler{gate: make(chan struct{}), started: make(chan struct{})}
+		h := NewAsyncHandler(inner, 2, test.policy)
+		// The goroutine holds the first record, and the buffer the next two.
+		h.Handle(NewRecord(time.Time{}, InfoLevel, "0", 0))
+		<-inner.started
+		for _, m := range []string{"1", "2", "3", "4", "5"} {
+			if err := h.Handle(NewRecord(time.Time{}, InfoLevel, m, 0)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		close(inner.gate)
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(inner.msgs, " "); got != test.want {
+			t.Errorf("%d: got %q, want %q", test.policy, got, test.want)
+		}
+		if got, want := h.Dropped(), int64(3); got != want {
+			t.Errorf("%d: dropped %d, want %d", test.policy, got, want)
+		}
+	}
+}
+
+func TestAsyncHandlerConcurrency(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowBlock, OverflowDropNewest, OverflowDropOldest} {
+		var buf bytes.Buffer
+		h := NewAsyncHandler(HandlerOptions{}.NewTextHandler(&buf), 4, policy)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l := New(h).With("a", 1)
+				for j := 0; j < 100; j++ {
+					l.Info("m", "j", j)
+				}
+			}()
+		}
+		wg.Wait()
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+		count := strings.Count(buf.String(), "\n")
+		if got := int64(count) + h.Dropped(); got != 1000 {
+			t.Errorf("%d: handled %d + dropped %d, want 1000 in all", policy, count, h.Dropped())
+		}
+		if policy == OverflowBlock && count != 1000 {
+			t.Errorf("blocking: handled %d, want 1000", count)
+		}
+	}
+}
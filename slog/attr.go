@@ -5,17 +5,23 @@
 package slog
 
 import (
+	"encoding/base64"
 	"fmt"
 	"math"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
 // Kind is the kind of an Attr's value.
 type Kind int
 
 // The following list is sorted alphabetically, but it's also important that
-// AnyKind is 0 so that a zero Attr's value is nil.
+// AnyKind is 0 so that a zero Attr's value is nil. Kinds added later go at
+// the end, so that the values of the others do not change.
 
 const (
 	AnyKind Kind = iota
@@ -26,6 +32,7 @@ const (
 	StringKind
 	TimeKind
 	Uint64Kind
+	GroupKind
 )
 
 var kindStrings = []string{
@@ -37,6 +44,7 @@ var kindStrings = []string{
 	"String",
 	"Time",
 	"Uint64",
+	"Group",
 }
 
 func (k Kind) String() string {
@@ -69,6 +77,25 @@ func Float64(key string, value float64) Attr {
 	return Attr{key: key, num: math.Float64bits(value), any: Float64Kind}
 }
 
+// Float32 returns an Attr for a float32. Its kind is Float64Kind, but
+// handlers output it with the precision of a float32, so that 0.1 is output
+// as 0.1 rather than 0.10000000149011612. Its Value is a float32.
+func Float32(key string, value float32) Attr {
+	return Attr{key: key, num: math.Float64bits(float64(value)), any: float32Kind{}}
+}
+
+// float32Kind marks the Attrs created by Float32.
+type float32Kind struct{}
+
+// floatBits returns the size of the Attr's floating-point value, in bits.
+// The Attr must have kind Float64Kind.
+func (a Attr) floatBits() int {
+	if _, ok := a.any.(float32Kind); ok {
+		return 32
+	}
+	return 64
+}
+
 // Bool returns an Attr for a bool.
 func Bool(key string, value bool) Attr {
 	u := uint64(0)
@@ -84,6 +111,63 @@ func Time(key string, value time.Time) Attr {
 	return Attr{key: key, num: uint64(value.UnixNano()), any: value.Location()}
 }
 
+// Date returns an Attr for the date of a time.Time in its location.
+// The value is a string of the form "2006-01-02"; the time of day is discarded.
+func Date(key string, value time.Time) Attr {
+	return String(key, value.Format(dateLayout))
+}
+
+const dateLayout = "2006-01-02"
+
+// HexBytes returns an Attr for a byte slice that handlers output as a string
+// of hexadecimal digits. It suits short binary values such as IDs.
+// The slice is encoded only when the Attr is output, so it must not be
+// modified until then.
+func HexBytes(key string, value []byte) Attr {
+	if value == nil {
+		value = []byte{} // output as empty, not nil
+	}
+	return Any(key, hexBytes(value))
+}
+
+// Base64Bytes returns an Attr for a byte slice that handlers output as a
+// string in standard base64 encoding. It suits large binary values.
+// The slice is encoded only when the Attr is output, so it must not be
+// modified until then.
+func Base64Bytes(key string, value []byte) Attr {
+	if value == nil {
+		value = []byte{} // output as empty, not nil
+	}
+	return Any(key, base64Bytes(value))
+}
+
+// hexBytes is the value of an Attr created by HexBytes.
+type hexBytes []byte
+
+func (b hexBytes) MarshalText() ([]byte, error) {
+	dst := make([]byte, 0, 2*len(b))
+	for _, c := range b {
+		dst = append(dst, hex[c>>4], hex[c&0xF])
+	}
+	return dst, nil
+}
+
+func (b hexBytes) String() string {
+	t, _ := b.MarshalText()
+	return string(t)
+}
+
+// base64Bytes is the value of an Attr created by Base64Bytes.
+type base64Bytes []byte
+
+func (b base64Bytes) MarshalText() ([]byte, error) {
+	dst := make([]byte, base64.StdEncoding.EncodedLen(len(b)))
+	base64.StdEncoding.Encode(dst, b)
+	return dst, nil
+}
+
+func (b base64Bytes) String() string { return base64.StdEncoding.EncodeToString(b) }
+
 // Duration returns an Attr for a time.Duration.
 func Duration(key string, value time.Duration) Attr {
 	return Attr{key: key, num: uint64(value.Nanoseconds()), any: DurationKind}
@@ -94,7 +178,8 @@ func Duration(key string, value time.Duration) Attr {
 // Given a value of one of Go's predeclared string, bool, or
 // (non-complex) numeric types, Any returns an Attr of kind
 // String, Bool, Uint64, Int64, or Float64. The width of the
-// original numeric type is not preserved.
+// original numeric type is not preserved, except that float32 values are
+// output with their own precision, as with [Float32].
 //
 // Given a time.Time or time.Duration value, Any returns an Attr of kind
 // TimeKind or DurationKind. The monotonic time is not preserved.
@@ -134,7 +219,7 @@ func Any(key string, value any) Attr {
 	case float64:
 		return Float64(key, v)
 	case float32:
-		return Float64(key, float64(v))
+		return Float32(key, v)
 	case Kind:
 		panic("cannot store a slog.Kind in an Attr")
 	case *time.Location:
@@ -144,6 +229,200 @@ func Any(key string, value any) Attr {
 	}
 }
 
+// Group returns an Attr for a group of Attrs.
+// Handlers output the Attrs of a group qualified by its key: the JSONHandler
+// as a nested object, and the TextHandler with keys of the form "key.attr".
+// A group with no Attrs is not output.
+func Group(key string, as ...Attr) Attr {
+	return Attr{key: key, any: groupAttrs(as)}
+}
+
+// groupAttrs is the value of an Attr of kind GroupKind.
+type groupAttrs []Attr
+
+// Map returns a group Attr with one Attr for each entry of m, produced by
+// calling fn with the entry's key and value. The Attrs are sorted by their
+// keys, so the output is deterministic. Unlike Any, Map does not use
+// reflection.
+func Map[K comparable, V any](key string, m map[K]V, fn func(K, V) Attr) Attr {
+	as := make([]Attr, 0, len(m))
+	for k, v := range m {
+		as = append(as, fn(k, v))
+	}
+	slices.SortFunc(as, func(a, b Attr) bool { return a.Key() < b.Key() })
+	return Group(key, as...)
+}
+
+// MapFunc is like Map, but orders the Attrs by the keys of m, as determined
+// by less.
+func MapFunc[K comparable, V any](key string, m map[K]V, fn func(K, V) Attr, less func(K, K) bool) Attr {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, less)
+	as := make([]Attr, len(keys))
+	for i, k := range keys {
+		as[i] = fn(k, m[k])
+	}
+	return Group(key, as...)
+}
+
+// ValidationErrors returns a group Attr describing failed validation, with
+// one Attr for each field in errs whose value is the field's list of error
+// messages. The fields are sorted, as with Map. The JSON handler formats the
+// group as an object whose members are arrays of strings.
+// If errs is empty, ValidationErrors returns the zero Attr, which handlers
+// do not output.
+func ValidationErrors(key string, errs map[string][]string) Attr {
+	if len(errs) == 0 {
+		return Attr{}
+	}
+	return Map(key, errs, func(field string, msgs []string) Attr {
+		return Any(field, msgs)
+	})
+}
+
+// TimeBucket returns a Time Attr for t truncated to a multiple of bucket,
+// as with t.Truncate(bucket), so that records in the same window of time
+// have the same value; for example, a bucket of time.Minute yields the
+// start of the minute. The value is formatted like any other time,
+// respecting [HandlerOptions.TimeFormat].
+func TimeBucket(key string, t time.Time, bucket time.Duration) Attr {
+	return Time(key, t.Truncate(bucket))
+}
+
+// CollapseGroup returns a group Attr like a, but with scalar members that
+// have equal values collapsed into one member, to save space when a group
+// repeats values. The collapsed member takes the place of the first of
+// them, and its key is their distinct keys joined by commas:
+//
+//	Group("g", String("a", "x"), Int("n", 1), String("b", "x"), String("a", "x"))
+//
+// collapses to
+//
+//	Group("g", String("a,b", "x"), Int("n", 1))
+//
+// Members that are groups are collapsed in turn. Members of kind AnyKind are
+// left alone, since their values may not be comparable. If a is not a group,
+// CollapseGroup returns it unchanged.
+func CollapseGroup(a Attr) Attr {
+	if a.Kind() != GroupKind {
+		return a
+	}
+	var out []Attr
+	var keys [][]string // of each member of out
+	for _, m := range a.group() {
+		switch m.Kind() {
+		case GroupKind:
+			out = append(out, CollapseGroup(m))
+			keys = append(keys, nil)
+			continue
+		case AnyKind:
+			out = append(out, m)
+			keys = append(keys, nil)
+			continue
+		}
+		found := false
+		for i, o := range out {
+			if keys[i] != nil && m.WithKey(o.Key()).Equal(o) {
+				if !slices.Contains(keys[i], m.Key()) {
+					keys[i] = append(keys[i], m.Key())
+					out[i] = o.WithKey(strings.Join(keys[i], ","))
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, m)
+			keys = append(keys, []string{m.Key()})
+		}
+	}
+	return Group(a.Key(), out...)
+}
+
+// Deadline returns an Attr for the time remaining until t, as with
+// Duration(key, time.Until(t)). The duration is negative if t is in the past.
+func Deadline(key string, t time.Time) Attr {
+	return Duration(key, time.Until(t))
+}
+
+// OmitEmpty returns a if its value is not empty, and the zero Attr otherwise.
+// Since handlers ignore Attrs with empty keys, an empty Attr wrapped in
+// OmitEmpty is not output.
+//
+// A value is empty if it is the zero value of its kind: the empty string,
+// zero, false, the zero time.Time or time.Duration, or, for AnyKind, a nil
+// value or the zero value of its type. Attrs created by ExplicitNull are not
+// empty.
+func OmitEmpty(a Attr) Attr {
+	if a.isEmpty() {
+		return Attr{}
+	}
+	return a
+}
+
+// ExplicitNull returns an Attr whose value is null, for schemas that
+// distinguish a null field from an absent one. Unlike an Attr with a nil
+// value, it is output even if [HandlerOptions.OmitEmpty] is set.
+// The JSON handler formats its value as null, and the text handler as
+// the string "null".
+func ExplicitNull(key string) Attr {
+	return Attr{key: key, any: explicitNull{}}
+}
+
+// explicitNull is the value of an Attr created by ExplicitNull.
+type explicitNull struct{}
+
+func (explicitNull) MarshalJSON() ([]byte, error) { return []byte("null"), nil }
+func (explicitNull) String() string               { return "null" }
+
+// TTL returns an Attr like a, annotated with a retention hint for pipelines
+// that expire log fields. Built-in handlers output a followed by a sibling
+// Attr whose key is a's key with the suffix "_ttl" and whose value is ttl.
+func TTL(a Attr, ttl time.Duration) Attr {
+	return Attr{key: a.key, any: ttlValue{a, ttl}}
+}
+
+// ttlValue is the value of an Attr created by TTL.
+type ttlValue struct {
+	attr Attr
+	ttl  time.Duration
+}
+
+// ttlSuffix is appended to the key of an Attr created by TTL to form the key
+// of its sibling.
+const ttlSuffix = "_ttl"
+
+// zeroTimeNanos is the num field of an Attr holding the zero time.Time.
+// The zero time is out of the range of UnixNano, so this is the only way to
+// recognize it.
+var zeroTimeNanos = uint64(time.Time{}.UnixNano())
+
+// isEmpty reports whether a's value is empty, as described at OmitEmpty.
+func (a Attr) isEmpty() bool {
+	switch a.Kind() {
+	case StringKind:
+		return a.str() == ""
+	case Int64Kind, Uint64Kind, BoolKind, DurationKind:
+		return a.num == 0
+	case Float64Kind:
+		return a.float() == 0
+	case TimeKind:
+		return a.num == zeroTimeNanos
+	case GroupKind:
+		return len(a.group()) == 0
+	case AnyKind:
+		if _, ok := a.any.(explicitNull); ok {
+			return false
+		}
+		return isNil(a.any) || reflect.ValueOf(a.any).IsZero()
+	default:
+		panic(fmt.Sprintf("bad kind: %s", a.Kind()))
+	}
+}
+
 //////////////// Accessors
 
 // Key returns the Attr's key.
@@ -159,6 +438,9 @@ func (a Attr) Value() any {
 	case Uint64Kind:
 		return a.num
 	case Float64Kind:
+		if a.floatBits() == 32 {
+			return float32(a.float())
+		}
 		return a.float()
 	case StringKind:
 		return a.str()
@@ -168,6 +450,8 @@ func (a Attr) Value() any {
 		return a.duration()
 	case TimeKind:
 		return a.time()
+	case GroupKind:
+		return a.group()
 	default:
 		panic("bad kind")
 	}
@@ -245,6 +529,19 @@ func (a Attr) time() time.Time {
 	return time.Unix(0, int64(a.num)).In(a.any.(*time.Location))
 }
 
+// Group returns the Attr's value as a []Attr. It panics
+// if the value is not a group.
+func (a Attr) Group() []Attr {
+	if g, w := a.Kind(), GroupKind; g != w {
+		panic(fmt.Sprintf("Attr kind is %s, not %s", g, w))
+	}
+	return a.group()
+}
+
+func (a Attr) group() []Attr {
+	return a.any.(groupAttrs)
+}
+
 //////////////// Other
 
 // WithKey returns an attr with the given key and the receiver's value.
@@ -272,6 +569,17 @@ func (a1 Attr) Equal(a2 Attr) bool {
 		return a1.float() == a2.float()
 	case TimeKind:
 		return a1.time().Equal(a2.time())
+	case GroupKind:
+		g1, g2 := a1.group(), a2.group()
+		if len(g1) != len(g2) {
+			return false
+		}
+		for i := range g1 {
+			if !g1[i].Equal(g2[i]) {
+				return false
+			}
+		}
+		return true
 	case AnyKind:
 		return a1.any == a2.any // may panic if non-comparable
 	default:
@@ -290,13 +598,15 @@ func (a Attr) appendValue(dst []byte) []byte {
 	case Uint64Kind:
 		return strconv.AppendUint(dst, a.num, 10)
 	case Float64Kind:
-		return strconv.AppendFloat(dst, a.float(), 'g', -1, 64)
+		return strconv.AppendFloat(dst, a.float(), 'g', -1, a.floatBits())
 	case BoolKind:
 		return strconv.AppendBool(dst, a.bool())
 	case DurationKind:
 		return append(dst, a.duration().String()...)
 	case TimeKind:
 		return append(dst, a.time().String()...)
+	case GroupKind:
+		return append(dst, fmt.Sprint(a.group())...)
 	case AnyKind:
 		return append(dst, fmt.Sprint(a.any)...)
 	default:
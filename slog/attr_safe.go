@@ -24,6 +24,8 @@ type Attr struct {
 	// If any is of type *time.Location, then the Kind is Time and time.Time
 	// value can be constructed from the Unix nanos in num and the location
 	// (monotonic time is not preserved).
+	// If any is of type groupAttrs, then the Kind is Group and any holds the
+	// group's Attrs.
 	// Otherwise, the Kind is Any and any is the value.
 	// (This implies that Attrs cannot store Kinds or *time.Locations.)
 	any any
@@ -36,6 +38,10 @@ func (a Attr) Kind() Kind {
 		return k
 	case *time.Location:
 		return TimeKind
+	case groupAttrs:
+		return GroupKind
+	case float32Kind:
+		return Float64Kind
 	default:
 		return AnyKind
 	}
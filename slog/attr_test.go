@@ -5,7 +5,12 @@
 package slog
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 	"unsafe"
@@ -23,6 +28,9 @@ func TestEqual(t *testing.T) {
 		Bool("key", false),
 		Any("key", &x),
 		Any("key", &y),
+		Group("key", Int("a", 1)),
+		Group("key", Int("a", 2)),
+		Group("key", Int("a", 1), Int("b", 2)),
 	}
 	for i, v1 := range vals {
 		for j, v2 := range vals {
@@ -346,3 +354,327 @@ func BenchmarkUnsafeStrings(b *testing.B) {
 	}
 	_ = d
 }
+
+func TestFloat32(t *testing.T) {
+	for _, f := range []float32{0.1, 1.5, -3.4028235e38, 1.0e-7, 16777216, 0} {
+		for _, a := range []Attr{Float32("f", f), Any("f", f)} {
+			if got, want := a.Kind(), Float64Kind; got != want {
+				t.Errorf("%v: got kind %s, want %s", f, got, want)
+			}
+			if got, ok := a.Value().(float32); !ok || got != f {
+				t.Errorf("%v: got Value %#v, want the float32", f, a.Value())
+			}
+			// The shortest representation of the float32 is output, and
+			// parses back to the same float32.
+			wantText := strconv.FormatFloat(float64(f), 'g', -1, 32)
+			if got := a.String(); got != wantText {
+				t.Errorf("%v: got %s, want %s", f, got, wantText)
+			}
+			var buf bytes.Buffer
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(a)
+			if err := NewJSONHandler(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			var got struct{ F float32 }
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.F != f {
+				t.Errorf("%v: JSON %s round-trips to %v", f, buf.String(), got.F)
+			}
+		}
+	}
+	if n := testing.AllocsPerRun(5, func() { _ = Float32("f", 1).Float64() }); n != 0 {
+		t.Errorf("got %v allocs, want zero", n)
+	}
+}
+
+func TestDate(t *testing.T) {
+	tm := time.Date(2000, 1, 2, 23, 4, 5, 0, time.FixedZone("", -5*60*60))
+	a := Date("d", tm)
+	if got, want := a.String(), "2000-01-02"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var buf bytes.Buffer
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(a)
+	if err := NewJSONHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `{"level":"INFO","msg":"m","d":"2000-01-02"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestCollapseGroup(t *testing.T) {
+	for _, test := range []struct {
+		in, want Attr
+	}{
+		{
+			Group("g", String("a", "x"), Int("n", 1), String("b", "x"), String("a", "x"), Int("m", 1)),
+			Group("g", String("a,b", "x"), Int("n,m", 1)),
+		},
+		{
+			// Equal values of different kinds are distinct.
+			Group("g", Int("i", 1), Uint64("u", 1), Float64("f", 1), String("s", "1")),
+			Group("g", Int("i", 1), Uint64("u", 1), Float64("f", 1), String("s", "1")),
+		},
+		{
+			// Subgroups are collapsed separately, and Any values are kept.
+			Group("g", Bool("a", true), Group("h", Bool("b", true), Bool("c", true)),
+				Any("p", []int{1}), Any("q", []int{1}), Bool("d", true)),
+			Group("g", Bool("a,d", true), Group("h", Bool("b,c", true)),
+				Any("p", []int{1}), Any("q", []int{1})),
+		},
+		{Int("n", 1), Int("n", 1)},
+		{Group("g"), Group("g")},
+	} {
+		got := CollapseGroup(test.in)
+		// Compare the text output, since Any values are not comparable.
+		if g, w := textOf(got), textOf(test.want); g != w {
+			t.Errorf("%s: got %s, want %s", textOf(test.in), g, w)
+		}
+	}
+}
+
+func textOf(a Attr) string {
+	var buf bytes.Buffer
+	r := NewRecord(time.Time{}, InfoLevel, "", 0)
+	r.AddAttrs(a)
+	NewTextHandler(&buf).Handle(r)
+	return strings.TrimSuffix(strings.TrimPrefix(buf.String(), "level=INFO msg= "), "\n")
+}
+
+func TestTimeBucket(t *testing.T) {
+	tm := time.Date(2022, 5, 6, 7, 8, 9, 10, time.UTC)
+	for _, test := range []struct {
+		bucket time.Duration
+		want   time.Time
+	}{
+		{time.Minute, time.Date(2022, 5, 6, 7, 8, 0, 0, time.UTC)},
+		{time.Hour, time.Date(2022, 5, 6, 7, 0, 0, 0, time.UTC)},
+		{15 * time.Minute, time.Date(2022, 5, 6, 7, 0, 0, 0, time.UTC)},
+		{0, tm},
+	} {
+		got := TimeBucket("t", tm, test.bucket)
+		if got.Key() != "t" || !got.Time().Equal(test.want) {
+			t.Errorf("%s: got %v, want %s", test.bucket, got, test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(TimeBucket("minute", tm, time.Minute))
+	if err := (HandlerOptions{TimeFormat: time.Kitchen}).NewJSONHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `{"level":"INFO","msg":"m","minute":"7:08AM"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	for _, test := range []struct {
+		offset   time.Duration
+		min, max time.Duration
+	}{
+		{time.Hour, 59 * time.Minute, time.Hour},
+		{-time.Hour, -time.Hour - time.Minute, -time.Hour},
+	} {
+		a := Deadline("d", time.Now().Add(test.offset))
+		if a.Kind() != DurationKind {
+			t.Fatalf("got kind %s, want %s", a.Kind(), DurationKind)
+		}
+		if got := a.Duration(); got < test.min || got > test.max {
+			t.Errorf("%s: got %s, want between %s and %s", test.offset, got, test.min, test.max)
+		}
+	}
+}
+
+func TestOmitEmpty(t *testing.T) {
+	var p *int
+	for _, test := range []struct {
+		attr Attr
+		want bool // kept
+	}{
+		{String("s", ""), false},
+		{String("s", "x"), true},
+		{Int("i", 0), false},
+		{Int("i", -1), true},
+		{Uint64("u", 0), false},
+		{Float64("f", 0), false},
+		{Float64("f", 0.5), true},
+		{Bool("b", false), false},
+		{Bool("b", true), true},
+		{Duration("d", 0), false},
+		{Duration("d", time.Second), true},
+		{Time("t", time.Time{}), false},
+		{Time("t", testTime), true},
+		{Any("a", nil), false},
+		{Any("a", p), false},
+		{Any("a", struct{ X int }{}), false},
+		{Any("a", struct{ X int }{1}), true},
+		{ExplicitNull("n"), true},
+	} {
+		got := OmitEmpty(test.attr)
+		if kept := got.Key() != ""; kept != test.want {
+			t.Errorf("%v: kept %t, want %t", test.attr, kept, test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(OmitEmpty(String("empty", "")), OmitEmpty(String("full", "x")))
+	if err := NewTextHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "level=INFO msg=m full=x\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	a := TTL(String("email", "a@b.c"), 30*24*time.Hour)
+	for _, test := range []struct {
+		name string
+		h    func(io.Writer) Handler
+		want string
+	}{
+		{"text", func(w io.Writer) Handler { return NewTextHandler(w) },
+			"level=INFO msg=m email=a@b.c email_ttl=720h0m0s n=1\n"},
+		{"json", func(w io.Writer) Handler { return NewJSONHandler(w) },
+			`{"level":"INFO","msg":"m","email":"a@b.c","email_ttl":2592000000000000,"n":1}` + "\n"},
+		{"with", func(w io.Writer) Handler { return NewTextHandler(w).With([]Attr{a}) },
+			"level=INFO msg=m email=a@b.c email_ttl=720h0m0s email=a@b.c email_ttl=720h0m0s n=1\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(a, Int("n", 1))
+			if err := test.h(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBytesEncodings(t *testing.T) {
+	b := []byte{0xde, 0xad, 0xbe, 0xef, 0xff}
+	for _, test := range []struct {
+		a        Attr
+		want     string
+		wantText string
+	}{
+		{HexBytes("id", b), "deadbeefff", "deadbeefff"},
+		{Base64Bytes("id", b), "3q2+7/8=", `"3q2+7/8="`},
+		{HexBytes("id", nil), "", ""},
+		{Base64Bytes("id", nil), "", ""},
+	} {
+		if got := test.a.String(); got != test.want {
+			t.Errorf("String: got %q, want %q", got, test.want)
+		}
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(test.a)
+		var buf bytes.Buffer
+		if err := NewJSONHandler(&buf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), `{"level":"INFO","msg":"m","id":"`+test.want+`"}`+"\n"; got != want {
+			t.Errorf("JSON: got %s, want %s", got, want)
+		}
+		buf.Reset()
+		if err := NewTextHandler(&buf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), "level=INFO msg=m id="+test.wantText+"\n"; got != want {
+			t.Errorf("text: got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	m := map[int]string{3: "c", 1: "a", 2: "b"}
+	itoa := func(k int, v string) Attr { return String(strconv.Itoa(k), v) }
+	want := Group("m", String("1", "a"), String("2", "b"), String("3", "c"))
+	if got := Map("m", m, itoa); !got.Equal(want) {
+		t.Errorf("Map: got %v, want %v", got, want)
+	}
+	desc := func(a, b int) bool { return a > b }
+	want = Group("m", String("3", "c"), String("2", "b"), String("1", "a"))
+	if got := MapFunc("m", m, itoa, desc); !got.Equal(want) {
+		t.Errorf("MapFunc: got %v, want %v", got, want)
+	}
+	if got := Map("m", map[string]int(nil), Int); len(got.Group()) != 0 {
+		t.Errorf("nil map: got %v, want empty group", got)
+	}
+
+	var buf bytes.Buffer
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(Map("counts", map[string]int{"b": 2, "a": 1}, Int))
+	if err := NewJSONHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `{"level":"INFO","msg":"m","counts":{"a":1,"b":2}}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	errs := map[string][]string{
+		"name":  {"is required"},
+		"email": {"is too long", "is not an address"},
+		"age":   {"is not a number", "must be positive"},
+	}
+	var buf bytes.Buffer
+	r := NewRecord(time.Time{}, InfoLevel, "invalid", 0)
+	r.AddAttrs(ValidationErrors("errors", errs), ValidationErrors("none", nil),
+		ValidationErrors("empty", map[string][]string{}))
+	if err := NewJSONHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"INFO","msg":"invalid","errors":{` +
+		`"age":["is not a number","must be positive"],` +
+		`"email":["is too long","is not an address"],` +
+		`"name":["is required"]}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	m := map[string]int{"requests": 10, "errors": 2, "retries": 1, "timeouts": 0}
+	h := NewJSONHandler(io.Discard)
+	b.Run("Map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(Map("counts", m, Int))
+			_ = h.Handle(r)
+		}
+	})
+	b.Run("Any", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(Any("counts", m))
+			_ = h.Handle(r)
+		}
+	})
+}
+
+func TestKindValues(t *testing.T) {
+	// The values of the exported Kinds must not change.
+	for i, k := range []Kind{AnyKind, BoolKind, DurationKind, Float64Kind, Int64Kind,
+		StringKind, TimeKind, Uint64Kind, GroupKind} {
+		if int(k) != i {
+			t.Errorf("%s = %d, want %d", k, k, i)
+		}
+	}
+	if got, want := GroupKind.String(), "Group"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -28,6 +28,8 @@ type Attr struct {
 	// is not preserved).
 	// If any is of type stringptr, then the Kind is String and the string value
 	// consists of the length in num and the pointer in any.
+	// If any is of type groupAttrs, then the Kind is Group and any holds the
+	// group's Attrs.
 	// Otherwise, the Kind is Any and any is the value.
 	// (This implies that Attrs cannot store values of type Kind, *time.Location
 	// or stringptr.)
@@ -46,6 +48,10 @@ func (a Attr) Kind() Kind {
 		return StringKind
 	case *time.Location:
 		return TimeKind
+	case groupAttrs:
+		return GroupKind
+	case float32Kind:
+		return Float64Kind
 	default:
 		return AnyKind
 	}
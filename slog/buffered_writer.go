@@ -0,0 +1,55 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// A bufferedWriter buffers the records written by a handler.
+// It is shared by a handler and all the handlers derived from it with With.
+type bufferedWriter struct {
+	mu       sync.Mutex
+	w        io.Writer // the underlying writer
+	bw       *bufio.Writer
+	interval time.Duration // if positive, flush this long after a buffered write
+	timer    *time.Timer   // non-nil while a flush is scheduled
+}
+
+func newBufferedWriter(w io.Writer, size int, interval time.Duration) *bufferedWriter {
+	return &bufferedWriter{w: w, bw: bufio.NewWriterSize(w, size), interval: interval}
+}
+
+// Write writes a single record. The record is never split between
+// writes to the underlying writer.
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(p) > w.bw.Available() && w.bw.Buffered() > 0 {
+		if err := w.bw.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	// If p is larger than the buffer, bufio.Writer writes it directly.
+	n, err := w.bw.Write(p)
+	if err == nil && w.interval > 0 && w.timer == nil && w.bw.Buffered() > 0 {
+		w.timer = time.AfterFunc(w.interval, func() { _ = w.Flush() })
+	}
+	return n, err
+}
+
+// Flush writes all buffered records to the underlying writer.
+func (w *bufferedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	return w.bw.Flush()
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingWriter records the calls to Write.
+type countingWriter struct {
+	mu     sync.Mutex
+	writes [][]byte
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (w *countingWriter) numWrites() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.writes)
+}
+
+func TestWriteBufferFlush(t *testing.T) {
+	var w countingWriter
+	h := HandlerOptions{WriteBufferSize: 4096}.NewTextHandler(&w)
+	h2 := h.With([]Attr{Int("a", 1)})
+	for i := 0; i < 3; i++ {
+		if err := h2.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := w.numWrites(); n != 0 {
+		t.Fatalf("got %d writes before Flush, want 0", n)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := w.numWrites(); n != 1 {
+		t.Fatalf("got %d writes after Flush, want 1", n)
+	}
+	want := "level=INFO msg=m a=1\nlevel=INFO msg=m a=1\nlevel=INFO msg=m a=1\n"
+	if got := string(w.writes[0]); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteBufferNoTearing(t *testing.T) {
+	var w countingWriter
+	// Each record is 31 bytes, so the buffer holds only one.
+	h := HandlerOptions{WriteBufferSize: 40}.NewTextHandler(&w)
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(String("k", "0123456789"))
+	for i := 0; i < 5; i++ {
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range w.writes {
+		if want := "level=INFO msg=m k=0123456789\n"; string(p) != want {
+			t.Errorf("got write %q, want %q", p, want)
+		}
+	}
+}
+
+func TestWriteBufferFlushInterval(t *testing.T) {
+	var w countingWriter
+	h := HandlerOptions{WriteBufferSize: 4096, FlushInterval: time.Millisecond}.NewJSONHandler(&w)
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	for start := time.Now(); w.numWrites() == 0; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("record was not flushed")
+		}
+	}
+}
+
+func BenchmarkWriteBuffer(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		size int
+	}{
+		{"unbuffered", 0},
+		{"buffered", 64 << 10},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			var writes int
+			w := writerFunc(func(p []byte) (int, error) {
+				writes++
+				return io.Discard.Write(p)
+			})
+			h := HandlerOptions{WriteBufferSize: bench.size}.NewJSONHandler(w)
+			r := NewRecord(time.Now(), InfoLevel, "this is a typical log message", 0)
+			r.AddAttrs(String("module", "github.com/google/go-cmp"), Int("count", 23))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = h.Handle(r)
+			}
+			_ = h.Flush()
+			b.ReportMetric(float64(writes)/float64(b.N), "writes/op")
+		})
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// syncingWriter is a countingWriter that counts calls to Sync.
+type syncingWriter struct {
+	countingWriter
+	syncs int
+}
+
+func (w *syncingWriter) Sync() error {
+	w.syncs++
+	return nil
+}
+
+func TestSyncLevels(t *testing.T) {
+	for _, bufSize := range []int{0, 4096} {
+		var w syncingWriter
+		h := HandlerOptions{WriteBufferSize: bufSize, SyncLevels: []Level{ErrorLevel}}.NewTextHandler(&w)
+		for _, test := range []struct {
+			level      Level
+			wantWrites int // with buffering
+			wantSyncs  int
+		}{
+			{InfoLevel, 0, 0},
+			{WarnLevel, 0, 0},
+			{ErrorLevel, 1, 1},
+			{InfoLevel, 1, 1},
+			{ErrorLevel, 2, 2},
+		} {
+			if err := h.Handle(NewRecord(time.Time{}, test.level, "m", 0)); err != nil {
+				t.Fatal(err)
+			}
+			if w.syncs != test.wantSyncs {
+				t.Errorf("size %d, after %s: got %d syncs, want %d", bufSize, test.level, w.syncs, test.wantSyncs)
+			}
+			if bufSize > 0 && w.numWrites() != test.wantWrites {
+				t.Errorf("size %d, after %s: got %d writes, want %d", bufSize, test.level, w.numWrites(), test.wantWrites)
+			}
+		}
+	}
+}
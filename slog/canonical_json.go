@@ -0,0 +1,208 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// canonicalize replaces the JSON value that follows offset start in s.buf
+// with its canonical form.
+func (s *handleState) canonicalize(start int) error {
+	canon, err := appendCanonicalJSON(nil, (*s.buf)[start:])
+	if err != nil {
+		return err
+	}
+	*s.buf = append((*s.buf)[:start], canon...)
+	return nil
+}
+
+// appendCanonicalJSON appends to dst the canonical form of the JSON value in
+// src, as defined by RFC 8785: object members are sorted by key, numbers are
+// formatted as in ECMAScript, strings use the minimal escaping, and there is
+// no insignificant whitespace.
+func appendCanonicalJSON(dst, src []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	v, err := decodeCanonical(dec)
+	if err != nil {
+		return dst, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return dst, errors.New("slog: trailing data after JSON value")
+	}
+	return appendCanonicalValue(dst, v)
+}
+
+// canonicalMember is an object member. Objects are decoded to slices of
+// members rather than maps so that duplicate keys are preserved.
+type canonicalMember struct {
+	key string
+	val any
+}
+
+// decodeCanonical decodes the next JSON value from dec. Objects are decoded as
+// []canonicalMember, arrays as []any and numbers as json.Number.
+func decodeCanonical(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		var ms []canonicalMember
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeCanonical(dec)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, canonicalMember{k.(string), v})
+		}
+		_, err := dec.Token() // '}'
+		return ms, err
+	case json.Delim('['):
+		vs := []any{}
+		for dec.More() {
+			v, err := decodeCanonical(dec)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		_, err := dec.Token() // ']'
+		return vs, err
+	default:
+		return tok, nil
+	}
+}
+
+func appendCanonicalValue(dst []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case bool:
+		return strconv.AppendBool(dst, v), nil
+	case string:
+		return appendCanonicalString(dst, v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return dst, err
+		}
+		return appendCanonicalNumber(dst, f), nil
+	case []any:
+		dst = append(dst, '[')
+		for i, e := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var err error
+			if dst, err = appendCanonicalValue(dst, e); err != nil {
+				return dst, err
+			}
+		}
+		return append(dst, ']'), nil
+	case []canonicalMember:
+		sort.SliceStable(v, func(i, j int) bool { return utf16Less(v[i].key, v[j].key) })
+		dst = append(dst, '{')
+		for i, m := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendCanonicalString(dst, m.key)
+			dst = append(dst, ':')
+			var err error
+			if dst, err = appendCanonicalValue(dst, m.val); err != nil {
+				return dst, err
+			}
+		}
+		return append(dst, '}'), nil
+	default:
+		return dst, errors.New("slog: unexpected JSON token")
+	}
+}
+
+// appendCanonicalNumber appends f formatted as by ECMAScript's
+// Number.prototype.toString, which is also what encoding/json does.
+func appendCanonicalNumber(dst []byte, f float64) []byte {
+	if f == 0 {
+		return append(dst, '0') // also for negative zero
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	n := len(dst)
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		if m := len(dst) - n; m >= 4 && dst[len(dst)-4] == 'e' && dst[len(dst)-3] == '-' && dst[len(dst)-2] == '0' {
+			dst[len(dst)-2] = dst[len(dst)-1]
+			dst = dst[:len(dst)-1]
+		}
+	}
+	return dst
+}
+
+// appendCanonicalString appends s as a JSON string, escaping only the
+// characters that must be escaped.
+func appendCanonicalString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			dst = utf8.AppendRune(dst, r)
+			i += size
+			continue
+		}
+		switch c {
+		case '"', '\\':
+			dst = append(dst, '\\', c)
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			if c < 0x20 {
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			} else {
+				dst = append(dst, c)
+			}
+		}
+		i++
+	}
+	return append(dst, '"')
+}
+
+// utf16Less reports whether a sorts before b when compared as sequences of
+// UTF-16 code units, as RFC 8785 requires.
+func utf16Less(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{`{"b":1,"a":2}`, `{"a":2,"b":1}`},
+		{` { "a" : [ 1 , { "z":true, "y":null } ] } `, `{"a":[1,{"y":null,"z":true}]}`},
+		{`[1.0, 1e2, 1E+21, 0.000001, 1e-7, -0, 3.14159, 100000000000000000000]`,
+			`[1,100,1e+21,0.000001,1e-7,0,3.14159,100000000000000000000]`},
+		{`"\u00e9\u2028<&>\/\u001f\n"`, "\"é\u2028<&>/\\u001f\\n\""},
+		// Sorted by UTF-16 code units: U+1F600 (a surrogate pair) sorts
+		// before U+FB33.
+		{`{"\ufb33":1,"\ud83d\ude00":2,"\r":3}`, "{\"\\r\":3,\"\U0001F600\":2,\"\uFB33\":1}"},
+		{`{"a":1,"a":2}`, `{"a":1,"a":2}`},
+	} {
+		got, err := appendCanonicalJSON(nil, []byte(test.in))
+		if err != nil {
+			t.Fatalf("%s: %v", test.in, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.in, got, test.want)
+		}
+	}
+	for _, in := range []string{`{`, `{"a":1} x`, ``} {
+		if _, err := appendCanonicalJSON(nil, []byte(in)); err == nil {
+			t.Errorf("%q: got nil error", in)
+		}
+	}
+}
+
+func TestJSONHandlerCanonical(t *testing.T) {
+	format := func(attrs ...Attr) string {
+		var buf bytes.Buffer
+		h := HandlerOptions{CanonicalJSON: true}.NewJSONHandler(&buf)
+		r := NewRecord(testTime, WarnLevel, "m", 0)
+		r.AddAttrs(attrs...)
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	got1 := format(Int("b", 1), Float64("a", math.Inf(1)), Any("m", map[string]any{"y": 1e21, "x": "é"}))
+	got2 := format(Any("m", map[string]any{"x": "é", "y": 1e21}), Float64("a", math.Inf(1)), Uint64("b", 1))
+	want := `{"a":"+Inf","b":1,"level":"WARN","m":{"x":"é","y":1e+21},"msg":"m","time":"2000-01-02T03:04:05Z"}` + "\n"
+	if got1 != want {
+		t.Errorf("\ngot  %s\nwant %s", got1, want)
+	}
+	if got1 != got2 {
+		t.Errorf("outputs differ:\n%s\n%s", got1, got2)
+	}
+
+	var buf bytes.Buffer
+	h := HandlerOptions{CanonicalJSON: true, LengthPrefixed: true}.NewJSONHandler(&buf)
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ReadFrame(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(rec), `{"level":"INFO","msg":"m"}`; got != want {
+		t.Errorf("framed: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+// ChannelHandler is a Handler that sends Records to a channel
+// instead of formatting them.
+type ChannelHandler struct {
+	opts       HandlerOptions
+	ch         chan<- Record
+	dropIfFull bool
+	attrGroups
+}
+
+// NewChannelHandler creates a ChannelHandler that sends to ch,
+// using the default options.
+func NewChannelHandler(ch chan<- Record, dropIfFull bool) *ChannelHandler {
+	return (HandlerOptions{}).NewChannelHandler(ch, dropIfFull)
+}
+
+// NewChannelHandler creates a ChannelHandler with the given options that sends
+// to ch. If dropIfFull is true, records that cannot be sent without blocking
+// are discarded. Only the Level option is used.
+func (opts HandlerOptions) NewChannelHandler(ch chan<- Record, dropIfFull bool) *ChannelHandler {
+	return &ChannelHandler{opts: opts, ch: ch, dropIfFull: dropIfFull}
+}
+
+// Enabled reports whether l is greater than or equal to the
+// minimum level.
+func (h *ChannelHandler) Enabled(l Level) bool {
+	minLevel := InfoLevel
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return l >= minLevel
+}
+
+// With returns a new ChannelHandler whose attributes consists
+// of h's attributes followed by attrs.
+func (h *ChannelHandler) With(attrs []Attr) Handler {
+	h2 := *h
+	h2.attrGroups = h.with(attrs)
+	return &h2
+}
+
+// WithGroup returns a new ChannelHandler whose later attributes, whether
+// passed to With or in records, are sent in a group with the given name.
+func (h *ChannelHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.attrGroups = h.withGroup(name)
+	return &h2
+}
+
+// Handle sends a copy of r to the channel. The copy shares no state with r,
+// and its attributes consist of h's attributes followed by those of r,
+// in h's groups.
+func (h *ChannelHandler) Handle(r Record) error {
+	c := r.withoutAttrs()
+	h.addAttrs(&c, r)
+	if !h.dropIfFull {
+		h.ch <- c
+		return nil
+	}
+	select {
+	case h.ch <- c:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChannelHandler(t *testing.T) {
+	ch := make(chan Record, 1)
+	h := HandlerOptions{Level: WarnLevel}.NewChannelHandler(ch, false)
+	if h.Enabled(InfoLevel) {
+		t.Error("enabled at Info, want disabled")
+	}
+	l := New(h).With("a", 1)
+	l.Warn("m", "b", 2)
+	r := <-ch
+	if got, want := r.Message(), "m"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+	if got, want := attrsSlice(r), []Attr{Int("a", 1), Int("b", 2)}; !attrsEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if file, _ := r.SourceLine(); file == "" {
+		t.Error("missing source line")
+	}
+}
+
+func TestChannelHandlerWithGroup(t *testing.T) {
+	ch := make(chan Record, 1)
+	l := New(NewChannelHandler(ch, false)).With("a", 1).WithGroup("g").With("b", 2)
+	l.Info("m", "c", 3)
+	want := []Attr{Int("a", 1), Group("g", Int("b", 2), Int("c", 3))}
+	if got := attrsSlice(<-ch); !attrsEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChannelHandlerClone(t *testing.T) {
+	ch := make(chan Record, 1)
+	h := NewChannelHandler(ch, false)
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	for i := 0; i < nAttrsInline+1; i++ {
+		r.AddAttrs(Int("k", i))
+	}
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := attrsSlice(r)
+	r.back[0] = Int("changed", 0)
+	if got := attrsSlice(<-ch); !attrsEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChannelHandlerDropIfFull(t *testing.T) {
+	ch := make(chan Record, 1)
+	h := NewChannelHandler(ch, true)
+	for _, m := range []string{"first", "second"} {
+		if err := h.Handle(NewRecord(time.Time{}, InfoLevel, m, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %d records, want 1", got)
+	}
+	if r := <-ch; r.Message() != "first" {
+		t.Errorf("got %q, want first", r.Message())
+	}
+}
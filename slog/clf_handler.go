@@ -0,0 +1,220 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog/internal/buffer"
+)
+
+// Keys of the attributes used by a CLFHandler.
+const (
+	CLFRemoteKey    = "remote"     // client address
+	CLFUserKey      = "user"       // authenticated user
+	CLFTimeKey      = "time"       // time of the request, if not the record's
+	CLFRequestKey   = "request"    // request line, as in "GET / HTTP/1.1"
+	CLFStatusKey    = "status"     // response status code
+	CLFSizeKey      = "size"       // response body size in bytes
+	CLFRefererKey   = "referer"    // Referer header, for Combined Log Format
+	CLFUserAgentKey = "user_agent" // User-Agent header, for Combined Log Format
+)
+
+// clfTimeFormat is the time layout of Common Log Format.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// CLFHandler is a Handler that writes records describing HTTP requests to an
+// io.Writer as lines in Common Log Format, or in Combined Log Format, for
+// tools that read web server logs:
+//
+//	remote - user [time] "request" status size
+//	remote - user [time] "request" status size "referer" "user_agent"
+//
+// The fields come from the attributes with the keys CLFRemoteKey and so on,
+// except that the time is the record's if there is no attribute with the key
+// CLFTimeKey. Missing fields, and sizes of zero, are written as "-".
+// The message and other attributes are not written. Only attributes outside
+// of groups are used, so those passed to With after WithGroup are ignored.
+type CLFHandler struct {
+	opts     HandlerOptions
+	combined bool
+	attrs    []Attr // from With
+	grouped  bool   // WithGroup was called
+	mu       *sync.Mutex
+	w        io.Writer
+}
+
+// NewCLFHandler creates a CLFHandler that writes to w, using the default
+// options. If combined is true, it writes Combined Log Format.
+func NewCLFHandler(w io.Writer, combined bool) *CLFHandler {
+	return (HandlerOptions{}).NewCLFHandler(w, combined)
+}
+
+// NewCLFHandler creates a CLFHandler with the given options that writes to w.
+// If combined is true, it writes Combined Log Format. Only the Level option
+// is used.
+func (opts HandlerOptions) NewCLFHandler(w io.Writer, combined bool) *CLFHandler {
+	return &CLFHandler{opts: opts, combined: combined, mu: &sync.Mutex{}, w: w}
+}
+
+// Enabled reports whether l is greater than or equal to the
+// minimum level.
+func (h *CLFHandler) Enabled(l Level) bool {
+	minLevel := InfoLevel
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return l >= minLevel
+}
+
+// With returns a new CLFHandler whose attributes consists
+// of h's attributes followed by attrs.
+func (h *CLFHandler) With(attrs []Attr) Handler {
+	if h.grouped {
+		return h
+	}
+	h2 := *h
+	h2.attrs = concat(h.attrs, attrs)
+	return &h2
+}
+
+// WithGroup returns a new CLFHandler that ignores the attributes of records,
+// and those passed to With, since they are in a group.
+func (h *CLFHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.grouped = true
+	return &h2
+}
+
+// Handle writes a line for r.
+func (h *CLFHandler) Handle(r Record) error {
+	var f clfFields
+	for _, a := range h.attrs {
+		f.set(a)
+	}
+	if !h.grouped {
+		r.Attrs(func(a Attr) bool {
+			f.set(a)
+			return true
+		})
+	}
+	if !f.hasTime && !r.Time().IsZero() {
+		f.time, f.hasTime = r.Time(), true
+	}
+
+	buf := buffer.New()
+	defer buf.Free()
+	appendCLFField(buf, f.remote)
+	buf.WriteString(" - ")
+	appendCLFField(buf, f.user)
+	buf.WriteByte(' ')
+	if f.hasTime {
+		buf.WriteByte('[')
+		*buf = f.time.AppendFormat(*buf, clfTimeFormat)
+		buf.WriteByte(']')
+	} else {
+		buf.WriteByte('-')
+	}
+	buf.WriteByte(' ')
+	appendCLFQuoted(buf, f.request)
+	buf.WriteByte(' ')
+	appendCLFField(buf, f.status)
+	buf.WriteByte(' ')
+	if f.size == "0" {
+		f.size = ""
+	}
+	appendCLFField(buf, f.size)
+	if h.combined {
+		buf.WriteByte(' ')
+		appendCLFQuoted(buf, f.referer)
+		buf.WriteByte(' ')
+		appendCLFQuoted(buf, f.userAgent)
+	}
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(*buf)
+	return err
+}
+
+// clfFields are the fields of a line of a CLFHandler.
+type clfFields struct {
+	remote, user, request, status, size, referer, userAgent string
+
+	time    time.Time
+	hasTime bool
+}
+
+// set sets the field for a, if any. Later attributes with the same key
+// take precedence.
+func (f *clfFields) set(a Attr) {
+	a = a.resolve()
+	switch a.Key() {
+	case CLFRemoteKey:
+		f.remote = a.String()
+	case CLFUserKey:
+		f.user = a.String()
+	case CLFTimeKey:
+		if a.Kind() == TimeKind {
+			f.time, f.hasTime = a.Time(), true
+		}
+	case CLFRequestKey:
+		f.request = a.String()
+	case CLFStatusKey:
+		f.status = a.String()
+	case CLFSizeKey:
+		f.size = a.String()
+	case CLFRefererKey:
+		f.referer = a.String()
+	case CLFUserAgentKey:
+		f.userAgent = a.String()
+	}
+}
+
+// appendCLFField appends an unquoted field, or "-" if it is empty.
+// Spaces and control characters are escaped, so that fields stay separate.
+func appendCLFField(buf *buffer.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+	appendCLFEscaped(buf, s, ' ')
+}
+
+// appendCLFQuoted appends a field in quotes, or "-" if it is empty.
+func appendCLFQuoted(buf *buffer.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+	buf.WriteByte('"')
+	appendCLFEscaped(buf, s, '"')
+	buf.WriteByte('"')
+}
+
+// appendCLFEscaped appends s, escaping backslashes, the byte special and
+// bytes that are not printable ASCII as Apache does, with \" for a quote and
+// \xhh otherwise.
+func appendCLFEscaped(buf *buffer.Buffer, s string, special byte) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' && special == '"':
+			buf.WriteString(`\"`)
+		case c == '\\':
+			buf.WriteString(`\\`)
+		case c == special || c < 0x20 || c >= 0x7f:
+			*buf = append(*buf, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCLFHandler(t *testing.T) {
+	tm := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	attrs := []any{
+		"remote", "127.0.0.1",
+		"user", "frank",
+		"request", "GET /apache_pb.gif HTTP/1.0",
+		"status", 200,
+		"size", 2326,
+		"referer", "http://www.example.com/start.html",
+		"user_agent", "Mozilla/4.08 [en] (Win98; I ;Nav)",
+		"other", "ignored",
+	}
+	for _, test := range []struct {
+		combined bool
+		want     string
+	}{
+		{false, `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326` + "\n"},
+		{true, `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 ` +
+			`"http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"` + "\n"},
+	} {
+		var buf bytes.Buffer
+		New(NewCLFHandler(&buf, test.combined)).LogAt(tm, InfoLevel, "request", attrs...)
+		if got := buf.String(); got != test.want {
+			t.Errorf("combined=%t:\ngot  %s\nwant %s", test.combined, got, test.want)
+		}
+	}
+}
+
+func TestCLFHandlerFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(NewCLFHandler(&buf, true)).With("remote", "::1")
+	// Missing fields and zero sizes are written as "-", an attribute can
+	// provide the time, and quotes and spaces are escaped.
+	tm := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.LogAt(time.Time{}, InfoLevel, "m", "time", tm, "request", `GET /"x" HTTP/1.1`,
+		"status", 304, "size", 0, "user", "a b")
+	l.WithGroup("g").LogAt(time.Time{}, InfoLevel, "m", "status", 500)
+	want := `::1 - a\x20b [02/Jan/2022:03:04:05 +0000] "GET /\"x\" HTTP/1.1" 304 - - -` + "\n" +
+		`::1 - - - - - - - -` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
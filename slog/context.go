@@ -22,3 +22,26 @@ func FromContext(ctx context.Context) *Logger {
 	}
 	return Default()
 }
+
+// CtxValue returns the value associated with key in ctx, if there is one
+// and it has type T.
+func CtxValue[T any](ctx context.Context, key any) (T, bool) {
+	v, ok := ctx.Value(key).(T)
+	return v, ok
+}
+
+// A ContextExtractor returns Attrs derived from a context.
+// See [HandlerOptions.ContextExtractors].
+type ContextExtractor func(context.Context) []Attr
+
+// ExtractContext returns a ContextExtractor that looks up the value
+// associated with key in a context. If there is one and it has type T,
+// the extractor returns the result of calling f on it.
+func ExtractContext[T any](key any, f func(T) []Attr) ContextExtractor {
+	return func(ctx context.Context) []Attr {
+		if v, ok := CtxValue[T](ctx, key); ok {
+			return f(v)
+		}
+		return nil
+	}
+}
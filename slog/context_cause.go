@@ -0,0 +1,14 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.20
+
+package slog
+
+import "context"
+
+// contextCause returns context.Cause(ctx).
+func contextCause(ctx context.Context) error {
+	return context.Cause(ctx)
+}
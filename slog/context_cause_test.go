@@ -0,0 +1,34 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.20
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHandlerAddContextCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	var buf bytes.Buffer
+	l := New(HandlerOptions{AddContextCause: true}.NewTextHandler(&buf)).WithContext(ctx)
+
+	l.LogAt(time.Time{}, InfoLevel, "m", "a", 1)
+	if got, want := buf.String(), "level=INFO msg=m a=1\n"; got != want {
+		t.Errorf("before cancel: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	cancel(errors.New("upstream timed out"))
+	l.LogAt(time.Time{}, InfoLevel, "m", "a", 1)
+	want := `level=INFO msg=m ctx_err="context canceled" ctx_cause="upstream timed out" a=1` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("after cancel:\ngot  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type requestInfo struct {
+	ID   string
+	User int
+}
+
+type requestInfoKey struct{}
+
+func TestCtxValue(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestInfoKey{}, requestInfo{"r1", 7})
+	if got, ok := CtxValue[requestInfo](ctx, requestInfoKey{}); !ok || got.ID != "r1" {
+		t.Errorf("got (%v, %t), want ({r1 7}, true)", got, ok)
+	}
+	if _, ok := CtxValue[*requestInfo](ctx, requestInfoKey{}); ok {
+		t.Error("got value of wrong type")
+	}
+	if _, ok := CtxValue[requestInfo](context.Background(), requestInfoKey{}); ok {
+		t.Error("got value from empty context")
+	}
+}
+
+func TestContextExtractors(t *testing.T) {
+	var buf bytes.Buffer
+	opts := HandlerOptions{
+		ContextExtractors: []ContextExtractor{
+			ExtractContext(requestInfoKey{}, func(ri requestInfo) []Attr {
+				return []Attr{String("request_id", ri.ID), Int("user", ri.User)}
+			}),
+		},
+	}
+	l := New(opts.NewTextHandler(&buf)).With("a", 1)
+	ctx := context.WithValue(context.Background(), requestInfoKey{}, requestInfo{"r1", 7})
+
+	l.WithContext(ctx).Info("m", "b", 2)
+	checkLogOutput(t, buf.String(), "time="+timeRE+` level=INFO msg=m a=1 request_id=r1 user=7 b=2`)
+	buf.Reset()
+	l.Info("m", "b", 2)
+	checkLogOutput(t, buf.String(), "time="+timeRE+` level=INFO msg=m a=1 b=2`)
+}
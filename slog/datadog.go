@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"context"
+	"io"
+)
+
+// DatadogOptions are options for a JSONHandler that uses Datadog's reserved
+// attributes.
+type DatadogOptions struct {
+	// Statuses maps levels to Datadog statuses. The status of a level is the
+	// value of the greatest key that is less than or equal to the level, or,
+	// for levels below all the keys, the value of the least key.
+	// If Statuses is nil, levels map to "debug", "info", "warning" and
+	// "error", and those below DebugLevel to "debug".
+	Statuses map[Level]string
+
+	// TraceIDs returns the IDs of the trace and span carried by a context,
+	// and reports whether there are any. If it is non-nil and reports true,
+	// the IDs are output as "dd.trace_id" and "dd.span_id", in a group
+	// with key "dd" that follows the built-in attributes and is outside the
+	// groups of WithGroup.
+	TraceIDs func(ctx context.Context) (traceID, spanID string, ok bool)
+}
+
+var defaultDatadogStatuses = map[Level]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warning",
+	ErrorLevel: "error",
+}
+
+// NewDatadogHandler creates a JSONHandler that writes to w, using the
+// default options and the given Datadog options.
+func NewDatadogHandler(w io.Writer, dd DatadogOptions) *JSONHandler {
+	return (HandlerOptions{}).NewDatadogHandler(w, dd)
+}
+
+// NewDatadogHandler creates a JSONHandler with the given options that writes
+// to w, and that uses Datadog's reserved attributes: the level is output
+// with key "status" and a value from dd.Statuses, and the message with key
+// "message". ReplaceAttr sees those keys and values.
+func (opts HandlerOptions) NewDatadogHandler(w io.Writer, dd DatadogOptions) *JSONHandler {
+	statuses := dd.Statuses
+	if statuses == nil {
+		statuses = defaultDatadogStatuses
+	}
+	h := opts.NewJSONHandler(w)
+	if dd.TraceIDs != nil {
+		// Datadog correlates only top-level IDs, so they are output
+		// outside the groups of WithGroup.
+		h.contextBuiltIns = func(ctx context.Context) []Attr {
+			traceID, spanID, ok := dd.TraceIDs(ctx)
+			if !ok {
+				return nil
+			}
+			return []Attr{Group("dd", String("trace_id", traceID), String("span_id", spanID))}
+		}
+	}
+	h.levelKey = "status"
+	h.msgKey = "message"
+	// Find the status of levels below all the keys.
+	var (
+		lowest string
+		min    Level
+		found  bool
+	)
+	for l, s := range statuses {
+		if !found || l < min {
+			lowest, min, found = s, l, true
+		}
+	}
+	h.levelString = func(l Level) string {
+		if s := levelMapValue(statuses, l); s != "" {
+			return s
+		}
+		return lowest
+	}
+	return h
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+func traceIDs(ctx context.Context) (string, string, bool) {
+	if ids, ok := CtxValue[[2]string](ctx, traceKey{}); ok {
+		return ids[0], ids[1], true
+	}
+	return "", "", false
+}
+
+func TestDatadogHandler(t *testing.T) {
+	traced := context.WithValue(context.Background(), traceKey{}, [2]string{"123", "456"})
+	for _, test := range []struct {
+		name  string
+		dd    DatadogOptions
+		level Level
+		ctx   context.Context
+		want  string
+	}{
+		{
+			"default statuses", DatadogOptions{}, WarnLevel + 1, nil,
+			`{"status":"warning","message":"m","a":1}`,
+		},
+		{
+			"statuses", DatadogOptions{Statuses: map[Level]string{DebugLevel: "debug", ErrorLevel + 4: "critical"}},
+			ErrorLevel + 4, nil,
+			`{"status":"critical","message":"m","a":1}`,
+		},
+		{
+			"trace", DatadogOptions{TraceIDs: traceIDs}, InfoLevel, traced,
+			`{"status":"info","message":"m","dd":{"trace_id":"123","span_id":"456"},"a":1}`,
+		},
+		{
+			"no trace", DatadogOptions{TraceIDs: traceIDs}, InfoLevel, nil,
+			`{"status":"info","message":"m","a":1}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(NewDatadogHandler(&buf, test.dd))
+			if test.ctx != nil {
+				l = l.WithContext(test.ctx)
+			}
+			l.LogAt(time.Time{}, test.level, "m", "a", 1)
+			if got, want := buf.String(), test.want+"\n"; got != want {
+				t.Errorf("\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestDatadogHandlerWithGroup(t *testing.T) {
+	// The trace IDs are top-level, where Datadog looks for them.
+	traced := context.WithValue(context.Background(), traceKey{}, [2]string{"123", "456"})
+	var buf bytes.Buffer
+	l := New(NewDatadogHandler(&buf, DatadogOptions{TraceIDs: traceIDs}))
+	l = l.With("a", 1).WithGroup("req").With("b", 2).WithContext(traced)
+	l.LogAt(time.Time{}, InfoLevel, "m", "c", 3)
+	want := `{"status":"info","message":"m","dd":{"trace_id":"123","span_id":"456"},"a":1,"req":{"b":2,"c":3}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestDatadogHandlerLowLevels(t *testing.T) {
+	// Levels below all the keys have the status of the least key.
+	for _, test := range []struct {
+		dd   DatadogOptions
+		want string
+	}{
+		{DatadogOptions{}, "debug"},
+		{DatadogOptions{Statuses: map[Level]string{InfoLevel: "info", ErrorLevel: "error"}}, "info"},
+	} {
+		var buf bytes.Buffer
+		if err := NewDatadogHandler(&buf, test.dd).Handle(NewRecord(time.Time{}, DebugLevel-4, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), `{"status":"`+test.want+`","message":"m"}`+"\n"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestDatadogHandlerReplaceAttr(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions{ReplaceAttr: upperCaseKey}.NewDatadogHandler(&buf, DatadogOptions{})
+	if err := h.With([]Attr{Int("a", 1)}).Handle(NewRecord(time.Time{}, ErrorLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `{"STATUS":"error","MESSAGE":"m","A":1}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+// Decimal returns an Attr for a decimal number written as a numeric literal,
+// like "12345678901234567890.01". The JSONHandler outputs it as a JSON
+// number with all its digits, rather than converting it to a float64 and
+// losing precision. A literal that is not a valid JSON number is output as a
+// string.
+//
+// Values of other types can get the same treatment by implementing
+// [Decimaler].
+func Decimal(key, literal string) Attr {
+	return Any(key, decimal(literal))
+}
+
+// decimal is the value of an Attr created by Decimal.
+type decimal string
+
+func (d decimal) MarshalText() ([]byte, error) {
+	return []byte(d), nil
+}
+
+func (d decimal) String() string { return string(d) }
+
+// A Decimaler is a decimal number, like a value of the types of decimal
+// libraries, which often marshal themselves to JSON as strings. The
+// JSONHandler outputs a Decimaler as a JSON number with all its digits.
+type Decimaler interface {
+	// Decimal returns the number as a numeric literal, like "12.50".
+	// A literal that is not a valid JSON number is output as a string.
+	Decimal() string
+}
+
+// decimalValue reports whether v, the value of an Attr of kind AnyKind, should
+// be output as a JSON number, and if so returns its literal. That is the case
+// only for values created by Decimal and for Decimalers: a String method that
+// happens to return digits, like that of HexBytes, does not make a value a
+// number.
+func decimalValue(v any) (string, bool) {
+	var s string
+	switch v := v.(type) {
+	case decimal:
+		s = string(v)
+	case Decimaler:
+		s = v.Decimal()
+	default:
+		return "", false
+	}
+	return s, isJSONNumber(s)
+}
+
+// isJSONNumber reports whether s is a number in the syntax of JSON
+// (RFC 8259, section 6).
+func isJSONNumber(s string) bool {
+	i := 0
+	digits := func() bool {
+		start := i
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		return i > start
+	}
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	if i < len(s) && s[i] == '0' {
+		i++
+	} else if !digits() {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if !digits() {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if !digits() {
+			return false
+		}
+	}
+	return i == len(s)
+}
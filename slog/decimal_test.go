@@ -0,0 +1,83 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// money is like the decimal types of financial libraries, which marshal
+// themselves to JSON as strings, but it implements Decimaler.
+type money struct {
+	units string
+}
+
+func (m money) String() string { return m.units }
+
+func (m money) Decimal() string { return m.units }
+
+func (m money) MarshalJSON() ([]byte, error) { return []byte(`"` + m.units + `"`), nil }
+
+// digits is a Stringer that is not a Decimaler.
+type digits string
+
+func (d digits) String() string { return string(d) }
+
+func TestDecimal(t *testing.T) {
+	const precise = "12345678901234567890.123456789"
+	for _, test := range []struct {
+		a        Attr
+		wantJSON string
+		wantText string
+	}{
+		{Decimal("d", precise), precise, precise},
+		{Decimal("d", "-1.5e-10"), "-1.5e-10", "-1.5e-10"},
+		{Decimal("d", "1,000"), `"1,000"`, `1,000`},
+		{Decimal("d", ""), `""`, ``},
+		{Any("d", money{precise}), precise, precise},
+		{Any("d", money{"N/A"}), `"N/A"`, `N/A`},
+		{Any("d", big.NewInt(0).Lsh(big.NewInt(1), 70)), "1180591620717411303424", "1180591620717411303424"},
+		{Any("d", big.NewFloat(1.25)), `"1.25"`, "1.25"},
+		// Values whose String methods return digits are not numbers.
+		{Any("d", digits("1234")), `"1234"`, "1234"},
+		{HexBytes("d", []byte{0x12, 0x34}), `"1234"`, "1234"},
+		{HexBytes("d", []byte{0x1e, 0x10}), `"1e10"`, "1e10"},
+		{Base64Bytes("d", []byte{0xd7, 0x6d, 0xf8}), `"1234"`, "1234"},
+	} {
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(test.a)
+		var jbuf, tbuf bytes.Buffer
+		if err := NewJSONHandler(&jbuf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewTextHandler(&tbuf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		want := `{"level":"INFO","msg":"m","d":` + test.wantJSON + "}\n"
+		if got := jbuf.String(); got != want {
+			t.Errorf("JSON: got %s, want %s", got, want)
+		}
+		want = "level=INFO msg=m d=" + test.wantText + "\n"
+		if got := tbuf.String(); got != want {
+			t.Errorf("text: got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestIsJSONNumber(t *testing.T) {
+	for _, s := range []string{"0", "-0", "1", "10", "-12.5", "0.001", "1e5", "1E+5", "2.5e-3"} {
+		if !isJSONNumber(s) {
+			t.Errorf("%q: got false, want true", s)
+		}
+	}
+	for _, s := range []string{"", "-", "01", "+1", "1.", ".5", "1e", "1e+", "0x10", "NaN", "1 ", "1_000"} {
+		if isJSONNumber(s) {
+			t.Errorf("%q: got true, want false", s)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"math"
+	"sync"
+)
+
+// DeltaHandler is a Handler that replaces the values of counter Attrs with
+// their change since the previous record, and passes the result to another
+// Handler. This suits cumulative counters whose rate is of interest.
+//
+// Counters are identified by their keys. Only Attrs of the Record, not those
+// added with With, are treated as counters, and only those whose values are
+// of kind Int64Kind, Uint64Kind or Float64Kind. The first value of a counter
+// is output as is, as if the previous value were zero. The delta of a
+// Uint64Kind counter that decreases is negative, and so has kind Int64Kind.
+//
+// A DeltaHandler and the handlers derived from it with With share the
+// previous values of counters. Records are handled one at a time, so deltas
+// are computed in the order that calls to Handle occur.
+type DeltaHandler struct {
+	inner Handler
+	state *deltaState
+}
+
+type deltaState struct {
+	mu   sync.Mutex
+	keys map[string]bool
+	last map[string]Attr
+}
+
+// NewDeltaHandler creates a DeltaHandler that computes the deltas of the
+// counters with the given keys, and passes records to inner.
+func NewDeltaHandler(inner Handler, keys ...string) *DeltaHandler {
+	s := &deltaState{keys: map[string]bool{}, last: map[string]Attr{}}
+	for _, k := range keys {
+		s.keys[k] = true
+	}
+	return &DeltaHandler{inner: inner, state: s}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *DeltaHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new DeltaHandler whose inner handler has the given
+// attributes. The new handler shares counter values with h.
+func (h *DeltaHandler) With(attrs []Attr) Handler {
+	return &DeltaHandler{inner: h.inner.With(attrs), state: h.state}
+}
+
+// WithGroup returns a new DeltaHandler whose inner handler has the given
+// group. The new handler shares counter values with h.
+func (h *DeltaHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &DeltaHandler{inner: h.inner.WithGroup(name), state: h.state}
+}
+
+// Handle replaces the counters in r with their deltas and passes the
+// result to the inner handler.
+func (h *DeltaHandler) Handle(r Record) error {
+	s := h.state
+	r2 := r.withoutAttrs()
+	// Hold the lock while handling, so records reach inner in the order
+	// their deltas were computed.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.Attrs(func(a Attr) bool {
+		if s.keys[a.Key()] {
+			a = s.delta(a)
+		}
+		r2.AddAttrs(a)
+		return true
+	})
+	return h.inner.Handle(r2)
+}
+
+// delta returns an Attr whose value is the difference between a and
+// the previous value of its counter, and remembers a.
+// s.mu must be held.
+func (s *deltaState) delta(a Attr) Attr {
+	prev, ok := s.last[a.Key()]
+	if !ok || prev.Kind() != a.Kind() {
+		prev = Attr{}
+	}
+	switch a.Kind() {
+	case Int64Kind:
+		s.last[a.Key()] = a
+		return Int64(a.Key(), a.Int64()-int64(prev.num))
+	case Uint64Kind:
+		s.last[a.Key()] = a
+		if a.num < prev.num {
+			return Int64(a.Key(), -int64(prev.num-a.num))
+		}
+		return Uint64(a.Key(), a.num-prev.num)
+	case Float64Kind:
+		s.last[a.Key()] = a
+		return Float64(a.Key(), a.float()-math.Float64frombits(prev.num))
+	default:
+		return a
+	}
+}
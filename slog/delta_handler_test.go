@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeltaHandler(t *testing.T) {
+	ch := make(chan Record, 10)
+	h := NewDeltaHandler(NewChannelHandler(ch, false), "requests", "bytes", "load")
+	h2 := h.With([]Attr{Int("requests", 1000)})
+
+	for _, test := range []struct {
+		h     Handler
+		attrs []Attr
+		want  []Attr
+	}{
+		{
+			h,
+			[]Attr{Int("requests", 10), Uint64("bytes", 100), Float64("load", 0.5), Int("other", 7)},
+			[]Attr{Int("requests", 10), Uint64("bytes", 100), Float64("load", 0.5), Int("other", 7)},
+		},
+		{
+			h2,
+			[]Attr{Int("requests", 25), Uint64("bytes", 40), Float64("load", 0.75), Int("other", 9)},
+			[]Attr{Int("requests", 1000), Int("requests", 15), Int64("bytes", -60), Float64("load", 0.25), Int("other", 9)},
+		},
+		{
+			h,
+			[]Attr{Int("requests", 25), String("bytes", "many")},
+			[]Attr{Int("requests", 0), String("bytes", "many")},
+		},
+	} {
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(test.attrs...)
+		if err := test.h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got := attrsSlice(<-ch); !attrsEqual(got, test.want) {
+			t.Errorf("got %v, want %v", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// depthMarker replaces values nested more deeply than
+// [HandlerOptions.MaxAnyDepth].
+const depthMarker = "!DEPTH"
+
+// limitDepth returns a, or, if a has kind AnyKind and its value is nested
+// more than max levels deep, an Attr whose value is a copy of a's truncated
+// to max levels, with deeper values replaced by depthMarker.
+func limitDepth(a Attr, max int) Attr {
+	if a.Kind() != AnyKind || a.any == nil {
+		return a
+	}
+	v := reflect.ValueOf(a.any)
+	if !exceedsDepth(v, max) {
+		return a
+	}
+	return Any(a.Key(), truncateDepth(v, max))
+}
+
+// isDepthLeaf reports whether v is output without looking inside it:
+// values that marshal themselves, byte slices and non-containers.
+func isDepthLeaf(v reflect.Value) bool {
+	if v.CanInterface() {
+		switch v.Interface().(type) {
+		case json.Marshaler, encoding.TextMarshaler, []byte:
+			return true
+		}
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return false
+	default:
+		return true
+	}
+}
+
+// maxIndirections bounds the chains of pointers and interfaces followed by
+// depthIndirect, which could otherwise be cycles.
+const maxIndirections = 100
+
+// depthIndirect follows pointers and interfaces from v. It returns the zero
+// Value if it reaches a nil one, and reports false if the chain is too long.
+func depthIndirect(v reflect.Value) (reflect.Value, bool) {
+	for i := 0; v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface; i++ {
+		if i == maxIndirections {
+			return v, false
+		}
+		if isDepthLeaf(v) {
+			return v, true
+		}
+		if v.IsNil() {
+			return reflect.Value{}, true
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+// exceedsDepth reports whether v contains containers nested more than
+// depth levels deep. It examines at most depth+1 levels.
+func exceedsDepth(v reflect.Value, depth int) bool {
+	v, ok := depthIndirect(v)
+	if !ok {
+		return true
+	}
+	if !v.IsValid() || isDepthLeaf(v) {
+		return false
+	}
+	if depth == 0 {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if exceedsDepth(iter.Value(), depth-1) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if exceedsDepth(v.Index(i), depth-1) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && exceedsDepth(v.Field(i), depth-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// truncateDepth returns a copy of v made of maps, slices and leaf values,
+// in which containers nested more than depth levels deep are replaced by
+// depthMarker. Structs become maps keyed by their JSON field names.
+func truncateDepth(v reflect.Value, depth int) any {
+	v, ok := depthIndirect(v)
+	if !ok {
+		return depthMarker
+	}
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	if isDepthLeaf(v) {
+		return v.Interface()
+	}
+	if depth == 0 {
+		return depthMarker
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = truncateDepth(iter.Value(), depth-1)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = truncateDepth(v.Index(i), depth-1)
+		}
+		return s
+	default: // reflect.Struct
+		m := map[string]any{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			m[name] = truncateDepth(v.Field(i), depth-1)
+		}
+		return m
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type depthNode struct {
+	Name string     `json:"name"`
+	Next *depthNode `json:"next,omitempty"`
+}
+
+func TestMaxAnyDepth(t *testing.T) {
+	nested := map[string]any{"a": map[string]any{"b": map[string]any{"c": map[string]any{"d": 1}}}}
+	cycle := &depthNode{Name: "x"}
+	cycle.Next = cycle
+	var self any
+	self = &self
+
+	for _, test := range []struct {
+		name     string
+		value    any
+		wantJSON string
+		wantText string
+	}{
+		{"shallow", map[string]int{"a": 1}, `{"a":1}`, "map[a:1]"},
+		{"at limit", map[string]any{"a": map[string]any{"b": []int{1}}}, `{"a":{"b":[1]}}`, "map[a:map[b:[1]]]"},
+		{"nested map", nested, `{"a":{"b":{"c":"!DEPTH"}}}`, "map[a:map[b:map[c:!DEPTH]]]"},
+		{"cycle", cycle, `{"name":"x","next":{"name":"x","next":{"name":"x","next":"!DEPTH"}}}`,
+			`"map[name:x next:map[name:x next:map[name:x next:!DEPTH]]]"`},
+		{"self", self, `"!DEPTH"`, "!DEPTH"},
+		{"marshaler", []any{[]any{[]any{testTime}}}, `[[["2000-01-02T03:04:05Z"]]]`, `"[[[2000-01-02 03:04:05 +0000 UTC]]]"`},
+		{"deeper marshaler", []any{[]any{[]any{[]any{testTime}}}}, `[[["!DEPTH"]]]`, "[[[!DEPTH]]]"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opts := HandlerOptions{MaxAnyDepth: 3}
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(Any("v", test.value))
+			var buf bytes.Buffer
+			if err := opts.NewJSONHandler(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := buf.String(), `{"level":"INFO","msg":"m","v":`+test.wantJSON+"}\n"; got != want {
+				t.Errorf("JSON:\ngot  %s\nwant %s", got, want)
+			}
+			buf.Reset()
+			if err := opts.NewTextHandler(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := buf.String(), "level=INFO msg=m v="+test.wantText+"\n"; got != want {
+				t.Errorf("text:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "reflect"
+
+// defaultDiffDepth is the depth to which Diff compares nested structs.
+const defaultDiffDepth = 4
+
+// Diff returns a group Attr with the fields of new that differ from those of
+// old, which are structs or pointers to structs of the same type.
+// It is equivalent to DiffDepth(key, old, new, 4).
+func Diff(key string, old, new any) Attr {
+	return DiffDepth(key, old, new, defaultDiffDepth)
+}
+
+// DiffDepth returns a group Attr with the exported fields of new that differ
+// from those of old, which are structs or pointers to structs of the same
+// type. Each Attr's key is the field's name, and its value is the field's
+// value in new.
+//
+// Fields that are themselves structs are compared recursively, and
+// output as groups containing only their changed fields, until a depth of
+// depth is reached. Beyond that, or if depth is less than 1, fields are
+// compared with reflect.DeepEqual and output in full if they differ.
+//
+// If old and new are not structs of the same type, the result is an Attr for
+// new if the values differ, or an empty group if they are equal.
+func DiffDepth(key string, old, new any, depth int) Attr {
+	a, ok := diffValues(key, reflect.ValueOf(old), reflect.ValueOf(new), depth)
+	if !ok {
+		return Group(key)
+	}
+	return a
+}
+
+// diffValues returns an Attr describing how new differs from old,
+// and reports whether there is any difference.
+func diffValues(key string, old, new reflect.Value, depth int) (Attr, bool) {
+	old, new = indirectStruct(old), indirectStruct(new)
+	if depth < 1 || !old.IsValid() || !new.IsValid() ||
+		old.Type() != new.Type() || old.Kind() != reflect.Struct {
+		switch {
+		case !new.IsValid():
+			return Any(key, nil), old.IsValid()
+		case old.IsValid() && reflect.DeepEqual(old.Interface(), new.Interface()):
+			return Attr{}, false
+		default:
+			return Any(key, new.Interface()), true
+		}
+	}
+	var as []Attr
+	t := new.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if a, ok := diffValues(f.Name, old.Field(i), new.Field(i), depth-1); ok {
+			as = append(as, a)
+		}
+	}
+	return Group(key, as...), len(as) > 0
+}
+
+// indirectStruct follows v if it is a non-nil pointer to a struct.
+// It returns the zero Value if v is a nil pointer or interface.
+func indirectStruct(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if e := v.Elem(); e.Kind() == reflect.Struct || v.Kind() == reflect.Interface {
+			return e
+		}
+	}
+	return v
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type diffAddress struct {
+	City string
+	Zip  string
+}
+
+type diffUser struct {
+	Name    string
+	Age     int
+	Tags    []string
+	Address diffAddress
+	Manager *diffUser
+	secret  string
+}
+
+func TestDiff(t *testing.T) {
+	base := diffUser{Name: "a", Age: 30, Tags: []string{"x"}, Address: diffAddress{"c", "1"},
+		Manager: &diffUser{Name: "n"}, secret: "s"}
+	for _, test := range []struct {
+		name  string
+		new   func(u *diffUser)
+		depth int
+		want  string
+	}{
+		{"one field", func(u *diffUser) { u.Age = 31 }, defaultDiffDepth, "u.Age=31"},
+		{"equal", func(u *diffUser) {}, defaultDiffDepth, ""},
+		{"unexported", func(u *diffUser) { u.secret = "t" }, defaultDiffDepth, ""},
+		{"slice", func(u *diffUser) { u.Tags = []string{"y"} }, defaultDiffDepth, "u.Tags=[y]"},
+		{"nested", func(u *diffUser) { u.Address.Zip = "2" }, defaultDiffDepth, "u.Address.Zip=2"},
+		{"depth", func(u *diffUser) { u.Address.Zip = "2" }, 1, `u.Address="{c 2}"`},
+		{"pointer", func(u *diffUser) { u.Manager = &diffUser{Name: "m"} }, defaultDiffDepth, "u.Manager.Name=m"},
+		{"nil pointer", func(u *diffUser) { u.Manager = nil }, defaultDiffDepth, "u.Manager=<nil>"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			u := base
+			test.new(&u)
+			a := DiffDepth("u", base, &u, test.depth)
+			var buf bytes.Buffer
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(a)
+			if err := NewTextHandler(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			want := "level=INFO msg=m"
+			if test.want != "" {
+				want += " " + test.want
+			}
+			if got := buf.String(); got != want+"\n" {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffNotStruct(t *testing.T) {
+	if got, want := Diff("k", 1, 2), Int("k", 2); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := Diff("k", 1, 1); got.Kind() != GroupKind || len(got.Group()) != 0 {
+		t.Errorf("got %v, want empty group", got)
+	}
+}
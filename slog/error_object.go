@@ -0,0 +1,117 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/exp/slog/internal/buffer"
+)
+
+// errorObject returns the group Attr for an error described at
+// [HandlerOptions.ErrorObjects].
+func (s *handleState) errorObject(key string, err error) Attr {
+	if key == "err" {
+		key = "error"
+	}
+	as := []Attr{
+		String("message", err.Error()),
+		String("type", fmt.Sprintf("%T", err)),
+	}
+	if s.errorStacks {
+		as = append(as, String("stack", goroutineStack()))
+	}
+	return Group(key, as...)
+}
+
+// Err returns an Attr with key "err", the key that [Logger.Error] uses,
+// whose value describes err and the errors it wraps, as a group with the
+// keys "msg", for the result of the Error method, and "cause", for the error
+// returned by its Unwrap method, if it has one that returns non-nil,
+// described in turn. An error in the chain that implements LogValuer is
+// described by its LogValue method instead. If err is nil, the value is nil,
+// which the JSON handler formats as null.
+//
+// The description is computed only when the Attr is output. The JSONHandler
+// formats any error value this way, so Err is needed only to make the intent
+// explicit, or to have the TextHandler and ReplaceAttr see the group.
+// [HandlerOptions.ErrorObjects] takes precedence over both.
+func Err(err error) Attr {
+	if isNil(err) {
+		return Any("err", nil)
+	}
+	return Any("err", errorChain{err})
+}
+
+// errorChain is the value of an Attr created by Err.
+type errorChain struct {
+	err error
+}
+
+// maxErrorCauses bounds the length of a chain of causes, in case an error
+// unwraps to itself.
+const maxErrorCauses = 100
+
+func (c errorChain) LogValue() Attr {
+	return describeError(c.err, maxErrorCauses)
+}
+
+// describeError returns the value described at Err for err, describing at
+// most n of its causes.
+func describeError(err error, n int) Attr {
+	if lv, ok := err.(LogValuer); ok {
+		return lv.LogValue()
+	}
+	as := []Attr{String("msg", err.Error())}
+	if u, ok := err.(interface{ Unwrap() error }); ok && n > 0 {
+		if cause := u.Unwrap(); !isNil(cause) {
+			as = append(as, describeError(cause, n-1).WithKey("cause"))
+		}
+	}
+	return Group("", as...)
+}
+
+// marshalsItself reports whether encoding/json formats v with a method of v.
+func marshalsItself(v any) bool {
+	switch v.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return true
+	}
+	return false
+}
+
+// appendError appends the JSON object described at Err for err.
+func (app jsonAppender) appendError(buf *buffer.Buffer, err error) error {
+	return app.appendValue(buf, describeError(err, maxErrorCauses).resolve())
+}
+
+// appendValue appends the value of a, formatting groups as objects. It is
+// for values that the handler does not otherwise see, so it does not apply
+// ReplaceAttr or the other options.
+func (app jsonAppender) appendValue(buf *buffer.Buffer, a Attr) error {
+	if a.Kind() != GroupKind {
+		return app.appendAttrValue(buf, a)
+	}
+	buf.WriteByte('{')
+	sep := false
+	for _, g := range a.group() {
+		g = g.resolve()
+		if g.Key() == "" {
+			continue
+		}
+		if sep {
+			buf.WriteByte(',')
+		}
+		app.appendKey(buf, g.Key())
+		if err := app.appendValue(buf, g); err != nil {
+			return err
+		}
+		sep = true
+	}
+	buf.WriteByte('}')
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerErrorObjects(t *testing.T) {
+	var buf bytes.Buffer
+	opts := HandlerOptions{ErrorObjects: true, StacktraceLevel: ErrorLevel}
+	l := New(opts.NewJSONHandler(&buf))
+
+	decode := func() map[string]any {
+		t.Helper()
+		var m map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		return m
+	}
+
+	l.Error("failed", &fs.PathError{Op: "open", Path: "f", Err: fs.ErrNotExist})
+	m := decode()
+	obj, ok := m["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("no error object: %v", m)
+	}
+	if got, want := obj["message"], "open f: file does not exist"; got != want {
+		t.Errorf("message: got %q, want %q", got, want)
+	}
+	if got, want := obj["type"], "*fs.PathError"; got != want {
+		t.Errorf("type: got %q, want %q", got, want)
+	}
+	if stack, _ := obj["stack"].(string); !strings.Contains(stack, "TestHandlerErrorObjects") {
+		t.Errorf("stack does not contain the test function:\n%s", stack)
+	}
+
+	// Below StacktraceLevel, there is no stack.
+	l.Warn("retrying", "cause", errors.New("busy"))
+	m = decode()
+	if got, want := m["cause"], map[string]any{"message": "busy", "type": "*errors.errorString"}; !equalMaps(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestHandlerErrorObjectsWith(t *testing.T) {
+	// Errors passed to With have no stacks, whether or not they are
+	// preformatted.
+	for _, test := range []struct {
+		name string
+		opts HandlerOptions
+	}{
+		{"preformatted", HandlerOptions{}},
+		{"KeyMinLevel", HandlerOptions{KeyMinLevel: map[string]Level{"other": DebugLevel}}},
+		{"FieldOrder", HandlerOptions{FieldOrder: []string{"msg"}}},
+	} {
+		opts := test.opts
+		opts.ErrorObjects, opts.StacktraceLevel = true, ErrorLevel
+		var buf bytes.Buffer
+		New(opts.NewJSONHandler(&buf)).With("w", errors.New("w")).Error("m", errors.New("r"))
+		var m map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		hasStack := func(key string) bool {
+			obj, _ := m[key].(map[string]any)
+			_, ok := obj["stack"]
+			return ok
+		}
+		if hasStack("w") {
+			t.Errorf("%s: With error has a stack", test.name)
+		}
+		if !hasStack("error") {
+			t.Errorf("%s: record error has no stack", test.name)
+		}
+	}
+}
+
+func equalMaps(got any, want map[string]any) bool {
+	g, ok := got.(map[string]any)
+	if !ok || len(g) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if g[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// loggedError is an error that describes itself as a LogValuer.
+type loggedError struct{ code int }
+
+func (e loggedError) Error() string  { return "failed" }
+func (e loggedError) LogValue() Attr { return Group("", Int("code", e.code)) }
+
+// marshalingError is an error with its own JSON encoding.
+type marshalingError struct{}
+
+func (marshalingError) Error() string                { return "failed" }
+func (marshalingError) MarshalJSON() ([]byte, error) { return []byte(`"marshaled"`), nil }
+
+func TestErr(t *testing.T) {
+	base := errors.New("base")
+	for _, test := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"plain", base, `"err":{"msg":"base"}`},
+		{"wrapped", fmt.Errorf("middle: %w", fmt.Errorf("inner: %w", base)),
+			`"err":{"msg":"middle: inner: base","cause":{"msg":"inner: base","cause":{"msg":"base"}}}`},
+		{"not wrapping", fmt.Errorf("formatted: %v", base), `"err":{"msg":"formatted: base"}`},
+		{"LogValuer", fmt.Errorf("call: %w", loggedError{42}),
+			`"err":{"msg":"call: failed","cause":{"code":42}}`},
+		{"nil", nil, `"err":null`},
+		{"typed nil", (*fs.PathError)(nil), `"err":null`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			New(NewJSONHandler(&buf)).LogAttrs(InfoLevel, "m", Err(test.err))
+			got := buf.String()
+			if i := strings.Index(got, `"err"`); i >= 0 {
+				got = strings.TrimSuffix(got[i:], "}\n")
+			}
+			if got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+
+	// The JSON handler formats any error value the same way, with its key.
+	var jbuf bytes.Buffer
+	New(NewJSONHandler(&jbuf)).LogAt(time.Time{}, InfoLevel, "m", "e", fmt.Errorf("call: %w", loggedError{42}),
+		"j", marshalingError{}, Group("g", Any("err", base)))
+	if got, want := jbuf.String(), `{"level":"INFO","msg":"m","e":{"msg":"call: failed","cause":{"code":42}},`+
+		`"j":"marshaled","g":{"err":{"msg":"base"}}}`+"\n"; got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+
+	// The text handler qualifies the keys.
+	var buf bytes.Buffer
+	New(NewTextHandler(&buf)).LogAttrs(InfoLevel, "m", Err(fmt.Errorf("a: %w", base)))
+	if got, want := buf.String(), `err.msg="a: base" err.cause.msg=base`+"\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+// FallbackHandler is a Handler that passes records to a primary Handler,
+// and to a fallback Handler only for records that the primary fails to
+// handle, so that they are not lost. For example, the fallback might write
+// to standard error when a remote service is unavailable.
+//
+// A FallbackHandler is enabled at a level if either handler is. Records
+// reach the primary only at levels where it is enabled, and the fallback
+// only at levels where it is enabled.
+type FallbackHandler struct {
+	primary  Handler
+	fallback Handler
+}
+
+// NewFallbackHandler creates a FallbackHandler that passes records to
+// primary, and to fallback when primary fails.
+func NewFallbackHandler(primary, fallback Handler) *FallbackHandler {
+	return &FallbackHandler{primary: primary, fallback: fallback}
+}
+
+// Enabled reports whether either handler is enabled at l.
+func (h *FallbackHandler) Enabled(l Level) bool {
+	return h.primary.Enabled(l) || h.fallback.Enabled(l)
+}
+
+// With returns a new FallbackHandler whose handlers both have the given
+// attributes.
+func (h *FallbackHandler) With(attrs []Attr) Handler {
+	return &FallbackHandler{
+		// Each handler owns its slice.
+		primary:  h.primary.With(concat(attrs, nil)),
+		fallback: h.fallback.With(concat(attrs, nil)),
+	}
+}
+
+// WithGroup returns a new FallbackHandler whose handlers both have the
+// given group.
+func (h *FallbackHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &FallbackHandler{
+		primary:  h.primary.WithGroup(name),
+		fallback: h.fallback.WithGroup(name),
+	}
+}
+
+// Handle passes r to the primary handler. If that fails, it passes a clone
+// of r to the fallback handler, and returns the primary's error, combined
+// with the fallback's if the fallback fails too.
+func (h *FallbackHandler) Handle(r Record) error {
+	if !h.primary.Enabled(r.Level()) {
+		return nil
+	}
+	err := h.primary.Handle(r)
+	if err == nil || !h.fallback.Enabled(r.Level()) {
+		return err
+	}
+	if ferr := h.fallback.Handle(r.Clone()); ferr != nil {
+		return multiError{err, ferr}
+	}
+	return err
+}
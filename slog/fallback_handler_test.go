@@ -0,0 +1,72 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// errWriter is an io.Writer that always fails.
+type errWriter struct{ err error }
+
+func (w errWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestFallbackHandler(t *testing.T) {
+	errDown := errors.New("down")
+	var fallback bytes.Buffer
+	h := NewFallbackHandler(
+		HandlerOptions{}.NewJSONHandler(errWriter{errDown}),
+		HandlerOptions{}.NewTextHandler(&fallback))
+	l := New(h).With("a", 1).WithGroup("g")
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(Int("b", 2))
+	err := l.Handler().Handle(r)
+	if !errors.Is(err, errDown) {
+		t.Errorf("got error %v, want %v", err, errDown)
+	}
+	if got, want := fallback.String(), "level=INFO msg=m a=1 g.b=2\n"; got != want {
+		t.Errorf("fallback: got %q, want %q", got, want)
+	}
+
+	// When the primary succeeds, the fallback is not called.
+	var primary bytes.Buffer
+	fallback.Reset()
+	h = NewFallbackHandler(HandlerOptions{}.NewTextHandler(&primary), HandlerOptions{}.NewTextHandler(&fallback))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if primary.Len() == 0 || fallback.Len() != 0 {
+		t.Errorf("got primary %q and fallback %q, want only primary output", primary.String(), fallback.String())
+	}
+
+	// Errors from both handlers are returned.
+	errAlsoDown := errors.New("also down")
+	var calls [2]int
+	h = NewFallbackHandler(failingHandler{err: errDown, calls: &calls[0]}, failingHandler{err: errAlsoDown, calls: &calls[1]})
+	err = h.Handle(r)
+	// Check the elements directly: errors.Is matches them only in Go 1.20
+	// and later.
+	errs, ok := err.(multiError)
+	if calls != [2]int{1, 1} || !ok || len(errs) != 2 || errs[0] != errDown || errs[1] != errAlsoDown {
+		t.Errorf("got calls %v and error %#v, want each handler called once and both errors", calls, err)
+	}
+}
+
+func TestFallbackHandlerEnabled(t *testing.T) {
+	h := NewFallbackHandler(
+		HandlerOptions{Level: WarnLevel}.NewTextHandler(&bytes.Buffer{}),
+		HandlerOptions{Level: DebugLevel}.NewTextHandler(&bytes.Buffer{}))
+	for _, l := range []Level{DebugLevel, InfoLevel, ErrorLevel} {
+		if !h.Enabled(l) {
+			t.Errorf("not enabled at %s", l)
+		}
+	}
+	if h.Enabled(DebugLevel - 1) {
+		t.Error("enabled below both handlers' levels")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"golang.org/x/exp/slices"
+)
+
+// A fieldMark records where a top-level field of text output starts.
+type fieldMark struct {
+	offset int    // in the buffer, of the separator or the key
+	sep    bool   // whether a separator precedes the key
+	key    string // of the field, qualified by its groups
+}
+
+// ordersFields reports whether text output should be reordered according
+// to HandlerOptions.FieldOrder.
+func (s *handleState) ordersFields() bool {
+	return len(s.h.opts.FieldOrder) > 0 && !s.h.app.nestsGroups()
+}
+
+// markField records that a text field with the given key starts at the end of
+// the buffer. It is called before the separator is appended.
+func (s *handleState) markField(key string) {
+	if s.ordersFields() {
+		s.fields = append(s.fields, fieldMark{len(*s.buf), s.sep, key})
+	}
+}
+
+// orderTextFields rearranges the fields after the first mark in the buffer in
+// the order of HandlerOptions.FieldOrder.
+func (s *handleState) orderTextFields() {
+	if len(s.fields) == 0 {
+		return
+	}
+	type field struct {
+		rank int
+		text []byte // without a separator
+	}
+	order := s.h.opts.FieldOrder
+	buf := *s.buf
+	fs := make([]field, len(s.fields))
+	for i, m := range s.fields {
+		end := len(buf)
+		if i+1 < len(s.fields) {
+			end = s.fields[i+1].offset
+		}
+		start := m.offset
+		if m.sep {
+			start++
+		}
+		fs[i] = field{fieldRank(order, m.key), buf[start:end]}
+	}
+	slices.SortStableFunc(fs, func(a, b field) bool { return a.rank < b.rank })
+	// The fields refer to buf, so build the result separately.
+	start := s.fields[0].offset
+	out := make([]byte, 0, len(buf)-start)
+	for i, f := range fs {
+		if i > 0 || s.fields[0].sep {
+			out = append(out, s.h.attrSep)
+		}
+		out = append(out, f.text...)
+	}
+	*s.buf = append(buf[:start], out...)
+}
+
+// orderJSONFields rearranges the members of the JSON object that follows
+// offset start in s.buf in the order of HandlerOptions.FieldOrder.
+func (s *handleState) orderJSONFields(start int) error {
+	type member struct {
+		rank int
+		text []byte // key and value
+	}
+	src := (*s.buf)[start:]
+	dec := json.NewDecoder(bytes.NewReader(src))
+	if _, err := dec.Token(); err != nil { // '{'
+		return err
+	}
+	var ms []member
+	for dec.More() {
+		begin := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		text := bytes.TrimLeft(src[begin:dec.InputOffset()], ",")
+		ms = append(ms, member{fieldRank(s.h.opts.FieldOrder, tok.(string)), text})
+	}
+	slices.SortStableFunc(ms, func(a, b member) bool { return a.rank < b.rank })
+	out := make([]byte, 0, len(src))
+	out = append(out, '{')
+	for i, m := range ms {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = append(out, m.text...)
+	}
+	out = append(out, '}')
+	*s.buf = append((*s.buf)[:start], out...)
+	return nil
+}
+
+// fieldRank returns the position of key in order, or len(order) if it is not
+// there, so that unlisted fields follow the listed ones.
+func fieldRank(order []string, key string) int {
+	if i := slices.Index(order, key); i >= 0 {
+		return i
+	}
+	return len(order)
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"hash/fnv"
+
+	"golang.org/x/exp/slices"
+)
+
+// fingerprintKey is the key of the Attr holding a record's fingerprint.
+const fingerprintKey = "fingerprint"
+
+// FingerprintHandler is a Handler that adds a fingerprint to each record, as
+// an Attr with key "fingerprint", and passes it to another Handler. The
+// fingerprint identifies records that describe the same event, for
+// deduplicating alerts: it is a hash of the message and of the values of the
+// attributes with the handler's keys, and ignores the time, the level and
+// all other attributes, like request IDs. Unlike a hash of a record's whole
+// content, it is the same for two records that differ only in such volatile
+// attributes.
+//
+// The keys are matched against the keys of the attributes passed to With
+// and of those of records; the attributes of groups are not examined, but a
+// group whose key matches contributes all its attributes. After a call to
+// WithGroup, the fingerprint is in the group.
+//
+// The fingerprint is a string of 16 hexadecimal digits. It is stable across
+// processes and versions of this package, but is not cryptographically
+// secure.
+type FingerprintHandler struct {
+	inner Handler
+	keys  []string // sorted
+	attrs []Attr   // the attributes from With with keys in keys
+}
+
+// NewFingerprintHandler creates a FingerprintHandler that computes
+// fingerprints over the message and the attributes with the given keys, and
+// passes records to inner.
+func NewFingerprintHandler(inner Handler, keys ...string) *FingerprintHandler {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	return &FingerprintHandler{inner: inner, keys: slices.Compact(keys)}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *FingerprintHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new FingerprintHandler whose inner handler has the given
+// attributes, and whose fingerprints include those with the handler's keys.
+func (h *FingerprintHandler) With(attrs []Attr) Handler {
+	h2 := *h
+	h2.inner = h.inner.With(attrs)
+	for _, a := range attrs {
+		if h.hasKey(a.Key()) {
+			h2.attrs = append(slices.Clip(h2.attrs), a)
+		}
+	}
+	return &h2
+}
+
+// WithGroup returns a new FingerprintHandler whose inner handler has the
+// given group.
+func (h *FingerprintHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.inner = h.inner.WithGroup(name)
+	return &h2
+}
+
+// Handle adds the fingerprint of r to it and passes it to the inner
+// handler.
+func (h *FingerprintHandler) Handle(r Record) error {
+	fp := h.fingerprint(r)
+	r = r.Clone()
+	r.AddAttrs(String(fingerprintKey, fp))
+	return h.inner.Handle(r)
+}
+
+func (h *FingerprintHandler) hasKey(key string) bool {
+	_, found := slices.BinarySearch(h.keys, key)
+	return found
+}
+
+// fingerprint returns the fingerprint of r.
+func (h *FingerprintHandler) fingerprint(r Record) string {
+	var selected []Attr
+	appendSelected := func(a Attr) bool {
+		if h.hasKey(a.Key()) {
+			selected = append(selected, a)
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		appendSelected(a)
+	}
+	r.Attrs(appendSelected)
+	// Order by key, so that the fingerprint does not depend on the order of
+	// the attributes. Values with the same key keep their order.
+	slices.SortStableFunc(selected, func(a, b Attr) bool { return a.Key() < b.Key() })
+
+	hash := fnv.New64a()
+	buf := []byte(r.Message())
+	for _, a := range selected {
+		// Separate the parts with bytes that cannot occur in UTF-8 text.
+		buf = append(buf, 0xff)
+		buf = append(buf, a.Key()...)
+		buf = append(buf, 0xfe, byte(a.Kind()))
+		buf = a.appendValue(buf)
+	}
+	hash.Write(buf)
+	sum := hash.Sum64()
+	var digits [16]byte
+	for i := len(digits) - 1; i >= 0; i-- {
+		digits[i] = hex[sum&0xf]
+		sum >>= 4
+	}
+	return string(digits[:])
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFingerprintHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewFingerprintHandler(NewTextHandler(&buf), "code", "service", "code")
+	fingerprintRE := regexp.MustCompile(`fingerprint=([0-9a-f]{16})$`)
+	fp := func(h Handler, msg string, attrs ...Attr) string {
+		t.Helper()
+		buf.Reset()
+		r := NewRecord(time.Now(), ErrorLevel, msg, 0)
+		r.AddAttrs(attrs...)
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		m := fingerprintRE.FindStringSubmatch(string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))))
+		if m == nil {
+			t.Fatalf("no fingerprint in %q", buf.String())
+		}
+		return m[1]
+	}
+
+	base := fp(h, "db down", String("request_id", "r1"), Int("code", 503), String("service", "api"))
+	for _, test := range []struct {
+		name  string
+		h     Handler
+		msg   string
+		attrs []Attr
+		same  bool
+	}{
+		{"other request ID", h, "db down", []Attr{String("request_id", "r2"), Int("code", 503), String("service", "api")}, true},
+		{"order", h, "db down", []Attr{String("service", "api"), Int("code", 503)}, true},
+		{"With", h.With([]Attr{String("service", "api"), String("host", "h1")}), "db down", []Attr{Int("code", 503)}, true},
+		{"other code", h, "db down", []Attr{String("request_id", "r1"), Int("code", 500), String("service", "api")}, false},
+		{"other message", h, "db up", []Attr{String("request_id", "r1"), Int("code", 503), String("service", "api")}, false},
+		{"missing key", h, "db down", []Attr{Int("code", 503)}, false},
+		{"kind", h, "db down", []Attr{String("code", "503"), String("service", "api")}, false},
+	} {
+		if got := fp(test.h, test.msg, test.attrs...); (got == base) != test.same {
+			t.Errorf("%s: got fingerprint %s, base %s; want same=%t", test.name, got, base, test.same)
+		}
+	}
+
+	// After WithGroup, the fingerprint is in the group.
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	buf.Reset()
+	if err := h.WithGroup("g").Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !regexp.MustCompile(`^level=INFO msg=m g.fingerprint=[0-9a-f]{16}\n$`).MatchString(got) {
+		t.Errorf("got %q", got)
+	}
+}
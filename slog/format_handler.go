@@ -0,0 +1,164 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"io"
+	"time"
+
+	"golang.org/x/exp/slog/internal/buffer"
+)
+
+// An Appender formats records for a [FormatHandler], letting packages
+// outside slog implement output formats, like YAML or TOML, while reusing the
+// handler's processing of attributes: ReplaceAttr, the group machinery and
+// the other [HandlerOptions].
+//
+// Each method appends to buf and returns the extended buffer, as with
+// strconv.AppendInt. The handler calls them to build one record at a time,
+// and then writes the result; an Appender needs no synchronization unless
+// its methods share state.
+//
+// The handler formats a record as a sequence of calls:
+//   - AppendStart, once.
+//   - For each attribute that is output, including the built-in ones, the
+//     separator, if it is not the first attribute at its level, and then
+//     either AppendKey followed by one of AppendString, AppendTime,
+//     AppendSource or AppendValue, or, for a group, AppendOpenGroup, the
+//     group's attributes and AppendCloseGroup. Empty groups are not output.
+//   - AppendEnd, once.
+//
+// The separator is [HandlerOptions.FieldSeparator], or a space if it is zero.
+type Appender interface {
+	// AppendStart appends what comes before the attributes of a record.
+	AppendStart(buf []byte) []byte
+
+	// AppendEnd appends what comes after the attributes of a record,
+	// before the newline that ends it.
+	AppendEnd(buf []byte) []byte
+
+	// AppendKey appends an attribute's key and what separates it from the
+	// value.
+	AppendKey(buf []byte, key string) []byte
+
+	// AppendOpenGroup appends the start of a group with the given key.
+	// The group's attributes follow, without a separator before the first.
+	AppendOpenGroup(buf []byte, key string) []byte
+
+	// AppendCloseGroup appends the end of the innermost open group.
+	AppendCloseGroup(buf []byte) []byte
+
+	// AppendString appends a string value, such as the message.
+	AppendString(buf []byte, s string) []byte
+
+	// AppendSource appends the source file and line of a record.
+	AppendSource(buf []byte, file string, line int) []byte
+
+	// AppendTime appends a time value, such as the time of a record.
+	AppendTime(buf []byte, t time.Time) ([]byte, error)
+
+	// AppendValue appends the value of a, which is not a group.
+	// If it returns an error, the handler appends a string describing it
+	// instead, to buf as it was passed.
+	AppendValue(buf []byte, a Attr) ([]byte, error)
+}
+
+// FormatHandler is a Handler that writes Records to an io.Writer in a format
+// defined by an [Appender], each followed by a newline.
+type FormatHandler struct {
+	*commonHandler
+}
+
+// NewFormatHandler creates a FormatHandler that writes to w, formatting
+// records with app, using the default options.
+func NewFormatHandler(w io.Writer, app Appender) *FormatHandler {
+	return (HandlerOptions{}).NewFormatHandler(w, app)
+}
+
+// NewFormatHandler creates a FormatHandler with the given options that writes
+// to w, formatting records with app. Options specific to the JSON and text
+// formats, like CanonicalJSON and QuoteChar, are ignored.
+func (opts HandlerOptions) NewFormatHandler(w io.Writer, app Appender) *FormatHandler {
+	sep := opts.FieldSeparator
+	if sep == 0 {
+		sep = ' '
+	}
+	return &FormatHandler{
+		&commonHandler{
+			app:      publicAppender{app},
+			interns:  opts.newInternTable(),
+			attrSep:  sep,
+			bufs:     opts.newBufferPool(),
+			w:        opts.newWriter(w),
+			opts:     opts,
+			runID:    opts.newRunID(),
+			resource: opts.Resource.attrs(),
+		},
+	}
+}
+
+// With returns a new FormatHandler whose attributes consists
+// of h's attributes followed by attrs.
+func (h *FormatHandler) With(attrs []Attr) Handler {
+	return &FormatHandler{commonHandler: h.commonHandler.with(attrs)}
+}
+
+// WithGroup returns a new FormatHandler whose later attributes, whether
+// passed to With or in records, are in a group with the given name.
+func (h *FormatHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &FormatHandler{commonHandler: h.commonHandler.withGroup(name)}
+}
+
+// Handle formats its argument Record with the handler's Appender, as
+// described at [Appender], and writes it in a single serialized call to
+// io.Writer.Write.
+func (h *FormatHandler) Handle(r Record) error {
+	return h.commonHandler.handle(r)
+}
+
+// publicAppender adapts an Appender to the appender interface of
+// commonHandler.
+type publicAppender struct {
+	a Appender
+}
+
+func (p publicAppender) appendStart(buf *buffer.Buffer) { *buf = p.a.AppendStart(*buf) }
+
+func (p publicAppender) appendEnd(buf *buffer.Buffer) { *buf = p.a.AppendEnd(*buf) }
+
+func (p publicAppender) appendKey(buf *buffer.Buffer, key string) { *buf = p.a.AppendKey(*buf, key) }
+
+func (publicAppender) nestsGroups() bool { return true }
+
+func (p publicAppender) appendOpenGroup(buf *buffer.Buffer, key string) {
+	*buf = p.a.AppendOpenGroup(*buf, key)
+}
+
+func (p publicAppender) appendCloseGroup(buf *buffer.Buffer) { *buf = p.a.AppendCloseGroup(*buf) }
+
+func (p publicAppender) appendString(buf *buffer.Buffer, s string) { *buf = p.a.AppendString(*buf, s) }
+
+func (p publicAppender) appendSource(buf *buffer.Buffer, file string, line int) {
+	*buf = p.a.AppendSource(*buf, file, line)
+}
+
+func (p publicAppender) appendTime(buf *buffer.Buffer, t time.Time) error {
+	b, err := p.a.AppendTime(*buf, t)
+	if err == nil {
+		*buf = b
+	}
+	return err
+}
+
+func (p publicAppender) appendAttrValue(buf *buffer.Buffer, a Attr) error {
+	b, err := p.a.AppendValue(*buf, a)
+	if err == nil {
+		*buf = b
+	}
+	return err
+}
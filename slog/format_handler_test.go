@@ -0,0 +1,110 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upperAppender formats records like "<LEVEL:INFO MSG:m G{A:1}>", with
+// keys in upper case.
+type upperAppender struct{}
+
+func (upperAppender) AppendStart(buf []byte) []byte { return append(buf, '<') }
+
+func (upperAppender) AppendEnd(buf []byte) []byte { return append(buf, '>') }
+
+func (upperAppender) AppendKey(buf []byte, key string) []byte {
+	return append(append(buf, strings.ToUpper(key)...), ':')
+}
+
+func (upperAppender) AppendOpenGroup(buf []byte, key string) []byte {
+	return append(append(buf, strings.ToUpper(key)...), '{')
+}
+
+func (upperAppender) AppendCloseGroup(buf []byte) []byte { return append(buf, '}') }
+
+func (upperAppender) AppendString(buf []byte, s string) []byte { return strconv.AppendQuote(buf, s) }
+
+func (upperAppender) AppendSource(buf []byte, file string, line int) []byte {
+	return append(buf, fmt.Sprintf("%s#%d", file, line)...)
+}
+
+func (upperAppender) AppendTime(buf []byte, t time.Time) ([]byte, error) {
+	return t.AppendFormat(buf, time.RFC3339), nil
+}
+
+var errBadValue = errors.New("bad value")
+
+func (upperAppender) AppendValue(buf []byte, a Attr) ([]byte, error) {
+	switch a.Kind() {
+	case StringKind:
+		return strconv.AppendQuote(buf, a.str()), nil
+	case TimeKind:
+		return a.Time().AppendFormat(buf, time.RFC3339), nil
+	case AnyKind:
+		if a.any == errBadValue {
+			return append(buf, "partial"...), errBadValue
+		}
+	}
+	return append(buf, fmt.Sprint(a.Value())...), nil
+}
+
+func TestFormatHandler(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		opts HandlerOptions
+		with func(Handler) Handler
+		want string
+	}{
+		{
+			"default",
+			HandlerOptions{},
+			func(h Handler) Handler { return h },
+			`<TIME:2000-01-02T03:04:05Z LEVEL:"INFO" MSG:"m" A:1 G{B:"x" H{C:true}} ERR:"!ERROR:bad value">`,
+		},
+		{
+			"with",
+			HandlerOptions{FieldSeparator: ','},
+			func(h Handler) Handler {
+				return h.With([]Attr{Int("w", 0)}).WithGroup("req").With([]Attr{Group("empty")})
+			},
+			`<TIME:2000-01-02T03:04:05Z,LEVEL:"INFO",MSG:"m",W:0,REQ{A:1,G{B:"x",H{C:true}},ERR:"!ERROR:bad value"}>`,
+		},
+		{
+			"ReplaceAttr",
+			HandlerOptions{ReplaceAttr: func(groups []string, a Attr) Attr {
+				if a.Key() == "time" || a.Key() == "err" {
+					return Attr{}
+				}
+				if len(groups) > 0 && a.Kind() != GroupKind {
+					return a.WithKey(strings.Join(groups, ".") + "." + a.Key())
+				}
+				return a
+			}},
+			func(h Handler) Handler { return h },
+			`<LEVEL:INFO MSG:"m" A:1 G{G.B:"x" H{G.H.C:true}}>`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := test.with(test.opts.NewFormatHandler(&buf, upperAppender{}))
+			r := NewRecord(testTime, InfoLevel, "m", 0)
+			r.AddAttrs(Int("a", 1), Group("g", String("b", "x"), Group("h", Bool("c", true))), Any("err", errBadValue))
+			if err := h.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// frameHeaderLen is the size of the length that precedes each record
+// written by a handler with the LengthPrefixed option.
+const frameHeaderLen = 4
+
+// putFrameHeader writes the length of the record following the header into
+// the header at the start of buf.
+func putFrameHeader(buf []byte) {
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-frameHeaderLen))
+}
+
+// ReadFrame reads one record written by a handler with the
+// [HandlerOptions.LengthPrefixed] option from r, and returns it without its
+// length header.
+// At the end of the input, ReadFrame returns io.EOF.
+// If the input ends within a record, it returns io.ErrUnexpectedEOF.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var hdr [frameHeaderLen]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	b := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLengthPrefixed(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions{LengthPrefixed: true}.NewJSONHandler(&buf)
+	msgs := []string{"a", "two\nlines", ""}
+	for _, m := range msgs {
+		if err := h.Handle(NewRecord(time.Time{}, InfoLevel, m, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, m := range msgs {
+		got, err := ReadFrame(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"level":"INFO","msg":` + string(appendQuotedJSONString(nil, m)) + `}`
+		if string(got) != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+	if _, err := ReadFrame(&buf); err != io.EOF {
+		t.Errorf("got %v, want EOF", err)
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	for _, in := range []string{"\x00\x00", "\x00\x00\x00\x05abc"} {
+		if _, err := ReadFrame(bytes.NewBufferString(in)); err != io.ErrUnexpectedEOF {
+			t.Errorf("%q: got %v, want ErrUnexpectedEOF", in, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "golang.org/x/exp/slices"
+
+// attrGroups holds the Attrs passed to With and the groups passed to
+// WithGroup, for handlers that keep Attrs as they are instead of formatting
+// them. Its methods return new values that share no mutable state with the
+// receiver.
+type attrGroups struct {
+	attrs  []Attr      // Attrs outside of any group
+	groups []attrGroup // open groups, outermost first
+}
+
+// An attrGroup is a group opened with WithGroup, and the Attrs passed to
+// With while it was the innermost group.
+type attrGroup struct {
+	name  string
+	attrs []Attr
+}
+
+// with returns g with as added to its innermost group.
+func (g attrGroups) with(as []Attr) attrGroups {
+	if n := len(g.groups); n > 0 {
+		g.groups = slices.Clone(g.groups)
+		g.groups[n-1].attrs = concat(g.groups[n-1].attrs, as)
+	} else {
+		g.attrs = concat(g.attrs, as)
+	}
+	return g
+}
+
+// withGroup returns g with a new innermost group.
+func (g attrGroups) withGroup(name string) attrGroups {
+	g.groups = append(slices.Clip(g.groups), attrGroup{name: name})
+	return g
+}
+
+// all returns the Attrs of g, followed by as in g's innermost group, with
+// each group as an Attr of kind GroupKind. Groups with no Attrs are included;
+// handlers do not output them.
+func (g attrGroups) all(as []Attr) []Attr {
+	if len(g.groups) == 0 && len(g.attrs) == 0 {
+		return as
+	}
+	for i := len(g.groups) - 1; i >= 0; i-- {
+		as = []Attr{Group(g.groups[i].name, concat(g.groups[i].attrs, as)...)}
+	}
+	return concat(g.attrs, as)
+}
+
+// addAttrs adds to dst the Attrs of g followed by those of r, as with all.
+func (g attrGroups) addAttrs(dst *Record, r Record) {
+	if len(g.groups) == 0 {
+		dst.AddAttrs(g.attrs...)
+		r.Attrs(func(a Attr) bool { dst.AddAttrs(a); return true })
+		return
+	}
+	as := make([]Attr, 0, r.NumAttrs())
+	r.Attrs(func(a Attr) bool { as = append(as, a); return true })
+	dst.AddAttrs(g.all(as)...)
+}
@@ -5,6 +5,9 @@
 package slog
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
@@ -12,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog/internal/buffer"
 )
 
@@ -39,10 +43,27 @@ type Handler interface {
 	// the receiver's attributes concatenated with the arguments.
 	// The Handler owns the slice: it may retain, modify or discard it.
 	With(attrs []Attr) Handler
+
+	// WithGroup returns a new Handler with the given group appended to
+	// the receiver's existing groups.
+	// The keys of all subsequent attributes, whether added by With or in a
+	// Record, should be qualified by the sequence of group names.
+	//
+	// A Handler should treat WithGroup as starting a Group of Attrs that ends
+	// at the end of the log event. That is,
+	//
+	//     logger.WithGroup("s").LogAttrs(level, msg, slog.Int("a", 1), slog.Int("b", 2))
+	//
+	// should behave like
+	//
+	//     logger.LogAttrs(level, msg, slog.Group("s", slog.Int("a", 1), slog.Int("b", 2)))
+	//
+	// If the name is empty, WithGroup returns the receiver.
+	WithGroup(name string) Handler
 }
 
 type defaultHandler struct {
-	attrs []Attr
+	attrGroups
 }
 
 func (*defaultHandler) Enabled(l Level) bool {
@@ -56,18 +77,25 @@ func (h *defaultHandler) Handle(r Record) error {
 	var b strings.Builder
 	b.WriteString(r.Level().String())
 	b.WriteByte(' ')
-	r.Attrs(func(a Attr) {
+	as := make([]Attr, 0, r.NumAttrs())
+	r.Attrs(func(a Attr) bool { as = append(as, a); return true })
+	for _, a := range h.all(as) {
 		fmt.Fprint(&b, a) // Attr.Format will print key=value
 		b.WriteByte(' ')
-	})
+	}
 	b.WriteString(r.Message())
 	return log.Output(4, b.String())
 }
 
 func (d *defaultHandler) With(as []Attr) Handler {
-	d2 := *d
-	d2.attrs = concat(d2.attrs, as)
-	return &d2
+	return &defaultHandler{d.with(as)}
+}
+
+func (d *defaultHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return d
+	}
+	return &defaultHandler{d.withGroup(name)}
 }
 
 // HandlerOptions are options for a TextHandler or JSONHandler.
@@ -77,10 +105,76 @@ type HandlerOptions struct {
 	// "file:line".
 	AddSource bool
 
+	// If SourceAsGroup is set along with AddSource, the "source" attribute
+	// is a group instead, with the function's fully qualified name under
+	// "function", the file under "file" and the line number under "line",
+	// so that they can be filtered on separately. The JSONHandler outputs
+	// it as an object, like
+	// {"function":"example.com/pkg.Fn","file":"/src/pkg/fn.go","line":23}.
+	// ReplaceAttr receives the whole group.
+	SourceAsGroup bool
+
+	// Add a "package" attribute to the output whose value is the import
+	// path of the package containing the function that created the record.
+	AddPackage bool
+
+	// If non-empty, Environment is output with key "env" on every record,
+	// after the message. It typically names the deployment environment,
+	// such as "prod" or "staging".
+	Environment string
+
+	// If AddRunID is set, a "run_id" attribute is output on every record,
+	// after the environment. Its value is a random UUID generated when the
+	// handler is created, so that the records of one run of a process can be
+	// told apart from those of earlier or later runs. Handlers derived from
+	// the handler with With share its run ID.
+	AddRunID bool
+
+	// If AddGroupPath is set, records handled within groups from WithGroup
+	// have a "group_path" attribute, after the run ID, whose value is the
+	// names of the groups, outermost first, joined by dots, as in "a.b".
+	// It is not itself in the groups.
+	AddGroupPath bool
+
+	// If non-nil, the attributes of Resource are output on every record,
+	// after the run ID. They describe the service producing the records,
+	// following the conventions of OpenTelemetry.
+	Resource *Resource
+
+	// ContextExtractors are called in order with the context of each
+	// record (see [Record.Context]), and the Attrs they return are output
+	// after those passed to With and before those of the record.
+	ContextExtractors []ContextExtractor
+
+	// If AddContextCause is set and the context of a record is done,
+	// the handler outputs the context's error with key "ctx_err" and its
+	// cause, as returned by context.Cause, with key "ctx_cause". They follow
+	// the attributes from ContextExtractors. Before Go 1.20, the cause is
+	// the context's error.
+	AddContextCause bool
+
+	// If non-empty, SourceTrimPrefix is removed from the start of the file
+	// name in the "source" attribute. File names that do not begin with
+	// the prefix are left intact.
+	// Set it to a module's root directory to output module-relative paths.
+	SourceTrimPrefix string
+
 	// Ignore records with levels below Level.Level().
+	// The level is consulted for each record, so using a *LevelVar lets the
+	// level be changed without rebuilding the handler.
 	// The default is InfoLevel.
 	Level Leveler
 
+	// If non-nil, MinLevel and MaxLevel restrict the handler to records
+	// within a band of levels: those below MinLevel.Level() or above
+	// MaxLevel.Level() are ignored. For example, a handler with a
+	// MinLevel of WarnLevel and a MaxLevel of ErrorLevel could write
+	// warnings and errors to one file, while a handler with a MinLevel of
+	// ErrorLevel+1 sends more severe records elsewhere. MinLevel takes
+	// precedence over Level.
+	MinLevel Leveler
+	MaxLevel Leveler
+
 	// If set, ReplaceAttr is called on each attribute of the message,
 	// and the returned value is used instead of the original. If the returned
 	// key is empty, the attribute is omitted from the output.
@@ -88,79 +182,650 @@ type HandlerOptions struct {
 	// The built-in attributes with keys "time", "level", "source", and "msg"
 	// are passed to this function first, except that time and level are omitted
 	// if zero, and source is omitted if AddSourceLine is false.
-	ReplaceAttr func(a Attr) Attr
+	//
+	// The groups argument holds the names of the groups containing the
+	// attribute, outermost first: those from WithGroup followed by those of
+	// Group attributes. It is empty for the built-in attributes.
+	// ReplaceAttr must not retain or modify groups.
+	ReplaceAttr func(groups []string, a Attr) Attr
+
+	// If OmitEmpty is set, attributes whose values are empty, as described
+	// at the OmitEmpty function, are not output. As with any group, a group
+	// all of whose attributes are omitted is not output either. Values are
+	// checked after ReplaceAttr is applied, so it can empty them. The
+	// built-in attributes are not affected.
+	OmitEmpty bool
+
+	// If BoolsAsFlags is set, Bool attributes are output as flags: those
+	// that are false are not output, and the TextHandler outputs those that
+	// are true as their key alone, as in "level=INFO msg=m verbose". JSON
+	// has no members without values, so the JSONHandler outputs them as
+	// usual. Values are checked after ReplaceAttr is applied. The built-in
+	// attributes are not affected.
+	BoolsAsFlags bool
+
+	// NormalizeValues normalizes the string values of attributes, for
+	// consistent indexing downstream. The built-in attributes are not
+	// affected. Values are normalized after ReplaceAttr is applied, and
+	// before OmitEmpty checks them.
+	NormalizeValues NormalizeOptions
+
+	// If MaskSecrets is set, string values of attributes are scanned for
+	// what look like credentials, which are replaced with "[REDACTED]":
+	// AWS access key IDs, the credentials of "Bearer" and "Basic"
+	// authorization, and long tokens that mix letters and digits with a
+	// high entropy, such as API keys. Outside an "Authorization:" header,
+	// the word after "Bearer" or "Basic" is masked only if it looks like
+	// credentials, so that text like "basic authentication" is left alone.
+	// The built-in attributes are not affected. This is a safety net, not a guarantee: secrets of other
+	// forms are not found, and random values that are not secrets, such as
+	// some identifiers and encoded data, may be masked too. Scanning has a
+	// cost for every string value.
+	MaskSecrets bool
+
+	// Transforms are applied in order to each attribute of the message,
+	// after ReplaceAttr. Each transform receives the result of the previous
+	// one. If a transform returns false, the attribute is omitted from the
+	// output and later transforms are not called. The groups argument is
+	// as for ReplaceAttr.
+	//
+	// Transforms see the same built-in attributes as ReplaceAttr.
+	Transforms []func(groups []string, a Attr) (Attr, bool)
+
+	// If LengthPrefixed is set, each record is preceded by its length in bytes
+	// as a 4-byte big-endian integer, and is not followed by a newline.
+	// Use [ReadFrame] to read the records back.
+	LengthPrefixed bool
+
+	// If WriteBufferSize is positive, records are buffered, and written to the
+	// io.Writer only when the buffer of that size is full or when
+	// the handler's Flush method is called. A record is never split between
+	// writes.
+	WriteBufferSize int
+
+	// BufferSize is the initial capacity of the buffers in which records are
+	// formatted. If records are often larger than the default of 1KB, a
+	// BufferSize to match avoids growing buffers repeatedly. Buffers are
+	// reused; those that grow beyond twice BufferSize, or 16KB if that is
+	// more, are dropped instead, to bound the memory retained.
+	BufferSize int
+
+	// If FlushInterval is positive and WriteBufferSize is set, buffered
+	// records are written at most FlushInterval after being handled.
+	FlushInterval time.Duration
+
+	// If non-nil, LevelKeySets restricts the attributes output for records
+	// at a given level to those whose keys are in the level's list.
+	// Attributes of records whose level has no entry are all output.
+	// The built-in attributes are not affected.
+	//
+	// Setting LevelKeySets disables the pre-formatting of attributes
+	// passed to With.
+	LevelKeySets map[Level][]string
+
+	// If non-nil, KeyMinLevel gives levels for attribute keys. An attribute
+	// whose key is in the map is output only if the key's level is at least
+	// the handler's minimum level, regardless of the level of the record,
+	// and of MaxLevel. For example,
+	// with {"payload": DebugLevel}, "payload" attributes are output only
+	// while the handler's Level is DebugLevel or lower. Since the handler's
+	// Level can change, so can which keys are output.
+	// The built-in attributes are not affected.
+	//
+	// Setting KeyMinLevel disables the pre-formatting of attributes
+	// passed to With.
+	KeyMinLevel map[string]Level
+
+	// If non-nil, KeyAliases maps alias keys to canonical ones. An attribute
+	// whose key is an alias is output with the canonical key instead, as if
+	// it had been created with that key; for example, with
+	// {"uid": "user_id"}, Int("uid", 7) is output as user_id=7. This eases
+	// migrating a codebase from one naming convention to another.
+	// Aliases are resolved before the other options that look at keys,
+	// including ReplaceAttr, LevelKeySets and KeyMinLevel, so those need
+	// only mention canonical keys.
+	// The built-in attributes and the attributes of groups are not
+	// affected.
+	KeyAliases map[string]string
+
+	// If LineColor is set, the TextHandler wraps each line in the ANSI escape
+	// sequence for its level's color, followed by a reset sequence.
+	// The color of a level is the value in LineColors of the greatest
+	// key that is less than or equal to the level. If LineColors is nil,
+	// a default map is used that colors debug lines faint, warnings
+	// yellow and errors red.
+	LineColor  bool
+	LineColors map[Level]string
+
+	// LevelIcons maps levels to icons, such as "⚠️", that the TextHandler
+	// writes before the level, separated from it by a space. The icon of a
+	// level is the value of the greatest key that is less than or equal to
+	// the level. If LevelIcons is nil, no icons are written.
+	LevelIcons map[Level]string
+
+	// If ShortLevel is set, levels are output with the first letters of
+	// their names, as in "I" for InfoLevel and "W+1" for WarnLevel+1,
+	// instead of the result of [Level.String].
+	// The level passed to ReplaceAttr is then a string.
+	ShortLevel bool
+
+	// If InternStrings is set, the handler keeps a single copy of strings
+	// it would otherwise allocate repeatedly: the "source" attribute passed
+	// to ReplaceAttr, the output of MarshalText in the TextHandler, and
+	// string values of attributes passed to With that the handler retains.
+	// This reduces allocations when the same strings recur.
+	// At most 1024 distinct strings are kept.
+	InternStrings bool
+
+	// If AutoNest is set, the JSONHandler treats the dots in attribute keys
+	// as separators of group names, and outputs the attributes in nested
+	// objects. For example, attributes with keys "http.method" and
+	// "http.status" are output as {"http":{"method":...,"status":...}}.
+	// Objects appear in the position of the first of their attributes.
+	// The built-in attributes are not nested.
+	// If a prefix is also the key of an attribute at the same level, as with
+	// keys "http" and "http.method", the attributes with that prefix are
+	// output with their full keys instead of being nested.
+	// Keys are split after ReplaceAttr and Transforms are applied.
+	//
+	// Setting AutoNest disables the pre-formatting of attributes
+	// passed to With.
+	AutoNest bool
+
+	// QuoteChar is the character the TextHandler uses to quote keys and
+	// values. It can be '"', the default, or '\''. With '"', strings are
+	// quoted as with strconv.Quote. With '\'', they are quoted the same way,
+	// except that single quotes are escaped and double quotes are not, and
+	// strings containing a single quote are always quoted.
+	QuoteChar byte
+
+	// FieldSeparator is the byte the TextHandler and the FormatHandler write
+	// between attributes, such as '\t' for tab-separated output.
+	// The default is ' '. The TextHandler quotes keys and values that
+	// contain the separator.
+	FieldSeparator byte
+
+	// If CanonicalJSON is set, the JSONHandler outputs each record in the
+	// canonical form of RFC 8785, so that records can be hashed or signed
+	// reproducibly: object keys are sorted, at every level, by their UTF-16
+	// code units; numbers are formatted as in ECMAScript, after conversion to
+	// float64; strings are escaped minimally; and there is no insignificant
+	// whitespace. Doing so requires re-encoding the output of each record.
+	CanonicalJSON bool
+
+	// FieldOrder lists keys of top-level fields, built-in or not, that the
+	// JSONHandler and TextHandler output first, in the given order. Other
+	// fields follow in their usual order. Keys are those in the output:
+	// for the TextHandler, the keys of Attrs in groups are qualified by the
+	// group names, as in "g.a", while for the JSONHandler a group is a single
+	// field. CanonicalJSON, which sorts all keys, takes precedence.
+	FieldOrder []string
+
+	// If NoHTMLEscape is set, the JSONHandler does not escape the characters
+	// '<', '>' and '&' in strings, as json.Encoder does with
+	// SetEscapeHTML(false). The line and paragraph separators U+2028 and
+	// U+2029 are always escaped.
+	NoHTMLEscape bool
+
+	// If Indent is non-empty, the JSONHandler outputs each record as
+	// multi-line JSON, as with json.MarshalIndent: every member of an object,
+	// including nested groups and the contents of values, begins on a new
+	// line indented by one copy of Indent per level of nesting. It is meant
+	// for reading logs during local development.
+	Indent string
+
+	// If MaxAnyDepth is positive, values of kind AnyKind are output with at
+	// most that many levels of nested maps, slices, arrays and structs.
+	// More deeply nested values are replaced by the string "!DEPTH".
+	// This guards against deeply nested or self-referential values.
+	// Truncated values are output as if structs were maps keyed by their
+	// JSON field names.
+	MaxAnyDepth int
+
+	// If non-nil, WriterFunc is called with each record to choose the
+	// io.Writer it is written to, instead of the handler's writer.
+	// If it returns nil, the handler's writer is used. Writers it returns are
+	// not buffered by WriteBufferSize. Writes to all writers are serialized.
+	WriterFunc func(r Record) io.Writer
+
+	// If AttrProvenance is set, the handler outputs a group with key
+	// "_provenance" after the other attributes, which maps the key of each
+	// attribute to where it came from: "with" for attributes passed to With,
+	// "context" for those from the record's context, and "call" for those
+	// of the record. If ReplaceAttr or the Transforms changed an attribute,
+	// "+replace" is appended, and if they removed it, "+drop" is appended.
+	// The keys are those output, after replacement. Attributes within groups
+	// are not tracked. AttrProvenance is meant for debugging.
+	//
+	// Setting AttrProvenance disables the pre-formatting of attributes
+	// passed to With.
+	AttrProvenance bool
+
+	// If TimeResolution is positive, the time of a record is output as an
+	// integer: the number of whole units of TimeResolution elapsed since
+	// TimeEpoch, rounded down. If TimeEpoch is the zero time, the Unix epoch
+	// is used. For example, a TimeResolution of time.Millisecond outputs
+	// Unix milliseconds. The time passed to ReplaceAttr is then an Int64.
+	TimeEpoch      time.Time
+	TimeResolution time.Duration
+
+	// If TimeFormat is non-empty, the JSONHandler outputs times, both that
+	// of a record and those of attributes of kind TimeKind, as strings
+	// formatted with time.Time.Format and the TimeFormat layout, instead of
+	// as with json.Marshal. ReplaceAttr sees times before they are
+	// formatted, so it can still output them differently. TimeResolution
+	// takes precedence over TimeFormat for the time of a record.
+	TimeFormat string
+
+	// DurationFormat is how the JSONHandler outputs durations. The default,
+	// DurationNanos, outputs them as json.Marshal does. ReplaceAttr sees
+	// durations before they are formatted.
+	DurationFormat DurationFormat
+
+	// If ErrorObjects is set, attributes whose values are errors are output
+	// as groups in the style of the Elastic Common Schema, with the keys
+	// "message", for the result of the Error method, "type", for the error's
+	// type, and, for records at or above StacktraceLevel, "stack", for the
+	// stack of the goroutine calling Handle. The "err" attribute added by
+	// [Logger.Error] is output with key "error".
+	ErrorObjects bool
+
+	// StacktraceLevel is the minimum level of records whose error objects
+	// include a stack; see ErrorObjects. If it is nil, stacks are not
+	// included. Stacks are never included for attributes passed to With,
+	// whatever the other options.
+	StacktraceLevel Leveler
+
+	// SyncLevels lists the levels of records after whose writing the handler
+	// flushes its buffer, if WriteBufferSize is set, and calls the Sync
+	// method of its writer, if it has one, as *os.File does. Records at
+	// other levels can then stay buffered while records at important
+	// levels, such as ErrorLevel, are made durable at once.
+	SyncLevels []Level
+}
+
+// unixEpoch is the default value of HandlerOptions.TimeEpoch.
+var unixEpoch = time.Unix(0, 0)
+
+// timeUnits returns t in units of opts.TimeResolution since opts.TimeEpoch.
+func (opts *HandlerOptions) timeUnits(t time.Time) int64 {
+	epoch := opts.TimeEpoch
+	if epoch.IsZero() {
+		epoch = unixEpoch
+	}
+	res := opts.TimeResolution
+	// Split t - epoch into whole seconds and nanoseconds, since a
+	// time.Duration only spans about 292 years.
+	secs := t.Unix() - epoch.Unix()
+	nanos := int64(t.Nanosecond() - epoch.Nanosecond())
+	if res%time.Second == 0 {
+		if nanos < 0 {
+			secs--
+		}
+		return floorDiv(secs, int64(res/time.Second))
+	}
+	// res < 1s or not a whole number of seconds: count in nanoseconds,
+	// which overflows only for spans of more than 292 years.
+	return floorDiv(secs*int64(time.Second)+nanos, int64(res))
+}
+
+// floorDiv returns a/b rounded toward negative infinity. b must be positive.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+var defaultLineColors = map[Level]string{
+	DebugLevel: "\x1b[2m",
+	InfoLevel:  "",
+	WarnLevel:  "\x1b[33m",
+	ErrorLevel: "\x1b[31m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// lineColor returns the escape sequence for lines at level l.
+func (opts *HandlerOptions) lineColor(l Level) string {
+	colors := opts.LineColors
+	if colors == nil {
+		colors = defaultLineColors
+	}
+	return levelMapValue(colors, l)
+}
+
+// levelMapValue returns the value in m of the greatest key that is less than
+// or equal to l, or the empty string if there is none.
+func levelMapValue(m map[Level]string, l Level) string {
+	var (
+		val   string
+		found bool
+		max   Level
+	)
+	for k, v := range m {
+		if k <= l && (!found || k > max) {
+			val, found, max = v, true, k
+		}
+	}
+	return val
 }
 
 type commonHandler struct {
 	opts              HandlerOptions
 	app               appender
+	json              bool // true if the handler outputs JSON
 	attrSep           byte // char separating attrs from each other
 	preformattedAttrs []byte
+	attrs             []Attr // attrs from With that are not pre-formatted
+	interns           *internTable
+	bufs              *buffer.Pool // if nil, use buffer.New
 	mu                sync.Mutex
 	w                 io.Writer
+	runID             string // if non-empty, output as "run_id"
+	resource          []Attr // from opts.Resource
+
+	// Groups from WithGroup.
+	groups          []string // outermost first
+	groupPrefix     string   // the groups followed by dots, for text output
+	nOpenGroups     int      // number of groups opened in preformattedAttrs
+	groupAttrStarts []int    // for each group, len(attrs) when it was added
+
+	// Overrides of the built-in attributes, for specialized handlers.
+	levelKey    string             // if non-empty, replaces "level"
+	msgKey      string             // if non-empty, replaces "msg"
+	levelString func(Level) string // if non-nil, formats levels
+	// If non-nil, returns Attrs to output after the built-in ones, outside
+	// any groups.
+	contextBuiltIns func(context.Context) []Attr
+}
+
+// newBufferPool returns the pool of buffers for a handler with the options,
+// or nil to use the default one.
+func (opts HandlerOptions) newBufferPool() *buffer.Pool {
+	if opts.BufferSize > 0 {
+		return buffer.NewPool(opts.BufferSize)
+	}
+	return nil
+}
+
+// newBuffer returns a buffer in which to format a record.
+func (h *commonHandler) newBuffer() *buffer.Buffer {
+	if h.bufs != nil {
+		return h.bufs.Get()
+	}
+	return buffer.New()
+}
+
+// freeBuffer releases a buffer returned by newBuffer.
+func (h *commonHandler) freeBuffer(b *buffer.Buffer) {
+	if h.bufs != nil {
+		h.bufs.Put(b)
+	} else {
+		b.Free()
+	}
+}
+
+// newInternTable returns the internTable for a handler with the options.
+func (opts HandlerOptions) newInternTable() *internTable {
+	if opts.InternStrings {
+		return newInternTable()
+	}
+	return nil
+}
+
+// newRunID returns the run ID of a handler with the options: a random
+// (version 4) UUID if AddRunID is set, and the empty string otherwise.
+func (opts HandlerOptions) newRunID() string {
+	if !opts.AddRunID {
+		return ""
+	}
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		// The system's source of randomness is unavailable; fall back on
+		// the time, which suffices to tell runs apart.
+		binary.BigEndian.PutUint64(u[:], uint64(time.Now().UnixNano()))
+	}
+	u[6] = u[6]&0x0f | 0x40 // version 4
+	u[8] = u[8]&0x3f | 0x80 // variant 10
+	return string(appendUUID(nil, u))
+}
+
+// newWriter returns the writer a handler with the options should write to.
+func (opts HandlerOptions) newWriter(w io.Writer) io.Writer {
+	if opts.WriteBufferSize > 0 {
+		return newBufferedWriter(w, opts.WriteBufferSize, opts.FlushInterval)
+	}
+	return w
+}
+
+// Flush writes any buffered records to the handler's io.Writer.
+// It does nothing unless [HandlerOptions.WriteBufferSize] is set.
+func (h *commonHandler) Flush() error {
+	if bw, ok := h.w.(*bufferedWriter); ok {
+		return bw.Flush()
+	}
+	return nil
 }
 
 // Enabled reports whether l is greater than or equal to the
-// minimum level.
+// minimum level, and no greater than the maximum level, if there is one.
 func (h *commonHandler) Enabled(l Level) bool {
-	minLevel := InfoLevel
-	if h.opts.Level != nil {
-		minLevel = h.opts.Level.Level()
+	if l < h.minLevel() {
+		return false
+	}
+	return h.opts.MaxLevel == nil || l <= h.opts.MaxLevel.Level()
+}
+
+// minLevel returns the level below which records are ignored.
+func (h *commonHandler) minLevel() Level {
+	switch {
+	case h.opts.MinLevel != nil:
+		return h.opts.MinLevel.Level()
+	case h.opts.Level != nil:
+		return h.opts.Level.Level()
+	default:
+		return InfoLevel
 	}
-	return l >= minLevel
+}
+
+// withWriter returns a copy of h that writes to w.
+func (h *commonHandler) withWriter(w io.Writer) *commonHandler {
+	h2 := h.with(nil)
+	h2.w = h.opts.newWriter(w)
+	return h2
 }
 
 func (h *commonHandler) with(as []Attr) *commonHandler {
 	h2 := &commonHandler{
 		app:               h.app,
+		json:              h.json,
 		attrSep:           h.attrSep,
 		opts:              h.opts,
-		preformattedAttrs: h.preformattedAttrs,
+		preformattedAttrs: slices.Clip(h.preformattedAttrs), // don't share appends
+		attrs:             h.attrs,
+		interns:           h.interns,
+		bufs:              h.bufs,
+		levelKey:          h.levelKey,
+		msgKey:            h.msgKey,
+		levelString:       h.levelString,
+		contextBuiltIns:   h.contextBuiltIns,
 		w:                 h.w,
+		runID:             h.runID,
+		resource:          h.resource,
+		groups:            h.groups,
+		groupPrefix:       h.groupPrefix,
+		nOpenGroups:       h.nOpenGroups,
+		groupAttrStarts:   h.groupAttrStarts,
 	}
-	// Pre-format the attributes as an optimization.
-	state := handleState{
-		h2,
-		(*buffer.Buffer)(&h2.preformattedAttrs),
-		false,
+	// Attrs are output in order: the preformatted ones, and then the
+	// others. So once an Attr is not preformatted, later ones aren't either.
+	if h.canPreformat() && len(h.attrs) == 0 {
+		// Pre-format the attributes as an optimization, up to the first
+		// one whose value comes from a LogValuer, which may be different
+		// for each record.
+		n := 0
+		for n < len(as) && !hasLogValuer(as[n]) {
+			n++
+		}
+		state := handleState{
+			h:   h2,
+			buf: (*buffer.Buffer)(&h2.preformattedAttrs),
+			sep: len(h2.preformattedAttrs) > 0,
+
+			nonBuiltIn: true,
+			nOpen:      h.nOpenGroups,
+		}
+		state.setDepth(len(h.groups))
+		for _, a := range as[:n] {
+			state.appendNonBuiltIn(a)
+		}
+		// Keep the groups open for later Attrs, unless they are empty.
+		state.trimHandlerGroups()
+		h2.nOpenGroups = state.nOpen
+		as = as[n:]
 	}
-	for _, a := range as {
-		state.appendAttr(a)
+	if len(as) > 0 {
+		h2.attrs = concat(h2.attrs, as)
+		for i, a := range h2.attrs[len(h.attrs):] {
+			if a.Kind() == StringKind {
+				h2.attrs[len(h.attrs)+i] = String(a.Key(), h.interns.string(a.str()))
+			}
+		}
+	}
+	return h2
+}
+
+// hasLogValuer reports whether a or any Attr in it, if it is a group, has a
+// value that is a LogValuer.
+func hasLogValuer(a Attr) bool {
+	switch a.Kind() {
+	case AnyKind:
+		_, ok := a.any.(LogValuer)
+		return ok
+	case GroupKind:
+		for _, g := range a.group() {
+			if hasLogValuer(g) {
+				return true
+			}
+		}
 	}
+	return false
+}
+
+// withGroup returns a copy of h whose later Attrs are in the group
+// with the given name.
+func (h *commonHandler) withGroup(name string) *commonHandler {
+	h2 := h.with(nil)
+	h2.groups = append(slices.Clip(h.groups), name)
+	h2.groupPrefix = h.groupPrefix + name + "."
+	h2.groupAttrStarts = append(slices.Clip(h.groupAttrStarts), len(h.attrs))
 	return h2
 }
 
+// canPreformat reports whether attributes passed to With can be formatted
+// once, independently of the records they will be output with.
+// Apart from AutoNest, which only the JSON handler implements, it depends
+// only on the options, so that handlers of different formats with the same
+// options preformat the same attributes.
+func (h *commonHandler) canPreformat() bool {
+	return h.opts.LevelKeySets == nil && h.opts.KeyMinLevel == nil &&
+		!h.opts.AttrProvenance && !h.autoNest() && len(h.opts.FieldOrder) == 0
+}
+
+// replaces reports whether the handler has ReplaceAttr or Transforms to
+// apply to attributes.
+func (h *commonHandler) replaces() bool {
+	return h.opts.ReplaceAttr != nil || len(h.opts.Transforms) > 0
+}
+
+// autoNest reports whether the handler nests attributes by their keys.
+func (h *commonHandler) autoNest() bool {
+	return h.opts.AutoNest && h.json
+}
+
 func (h *commonHandler) handle(r Record) error {
-	rep := h.opts.ReplaceAttr
-	state := handleState{h, buffer.New(), false}
-	defer state.buf.Free()
+	return h.handleReplaced(r, nil)
+}
+
+// handleReplaced is like handle, but takes the results of replacing Attrs
+// from reps, if it is non-nil, and records them there when it has none.
+func (h *commonHandler) handleReplaced(r Record, reps *replacements) error {
+	rep := h.replaces()
+	state := handleState{h: h, buf: h.newBuffer(), reps: reps}
+	if h.opts.ErrorObjects && h.opts.StacktraceLevel != nil {
+		state.errorStacks = r.Level() >= h.opts.StacktraceLevel.Level()
+	}
+	state.keys, state.filterKeys = h.opts.LevelKeySets[r.Level()]
+	defer h.freeBuffer(state.buf)
+	if h.opts.LengthPrefixed {
+		// Reserve space for the length.
+		state.buf.Write(make([]byte, frameHeaderLen))
+	}
+	color := ""
+	if h.opts.LineColor && !h.json {
+		color = h.opts.lineColor(r.Level())
+		state.buf.WriteString(color)
+	}
 	h.app.appendStart(state.buf)
 	// time
 	if !r.Time().IsZero() {
 		key := "time"
 		val := r.Time().Round(0) // strip monotonic to match Attr behavior
-		if rep == nil {
+		switch {
+		case h.opts.TimeResolution > 0:
+			n := h.opts.timeUnits(val)
+			if !rep {
+				state.appendKey(key)
+				state.appendAttrValue(Int64(key, n))
+			} else {
+				state.appendAttr(Int64(key, n))
+			}
+		case !rep:
 			state.appendKey(key)
 			state.appendTime(val)
-		} else {
+		default:
 			state.appendAttr(Time(key, val))
 		}
 	}
 	// level
 	key := "level"
+	if h.levelKey != "" {
+		key = h.levelKey
+	}
 	val := r.Level()
-	if rep == nil {
+	if !h.json && h.opts.LevelIcons != nil {
+		if icon := levelMapValue(h.opts.LevelIcons, val); icon != "" {
+			state.markField(key)
+			state.appendSep()
+			state.buf.WriteString(icon)
+			state.sep = true
+		}
+	}
+	switch {
+	case h.levelString != nil:
+		state.appendBuiltInString(rep, key, h.levelString(val))
+	case h.opts.ShortLevel:
+		state.appendBuiltInString(rep, key, val.shortString())
+	case !rep:
 		state.appendKey(key)
 		state.appendString(val.String())
-	} else {
+	default:
 		state.appendAttr(Any(key, val))
 	}
 	// source
 	if h.opts.AddSource {
-		file, line := r.SourceLine()
+		f := r.frame()
+		file, line := strings.TrimPrefix(f.File, h.opts.SourceTrimPrefix), f.Line
 		if file != "" {
 			key := "source"
-			if rep == nil {
+			if h.opts.SourceAsGroup {
+				state.appendAttr(Group(key,
+					String("function", f.Function),
+					String("file", file),
+					Int("line", line)))
+			} else if !rep {
 				state.appendKey(key)
 				h.app.appendSource(state.buf, file, line)
 			} else {
@@ -168,38 +833,178 @@ func (h *commonHandler) handle(r Record) error {
 				buf.WriteString(file) // TODO: escape?
 				buf.WriteByte(':')
 				itoa((*[]byte)(buf), line, -1)
-				s := buf.String()
+				s := h.interns.bytes(*buf)
 				buf.Free()
 				state.appendAttr(String(key, s))
 			}
 		}
 	}
+	// package
+	if h.opts.AddPackage {
+		if pkg := funcPackage(r.frame().Function); pkg != "" {
+			state.appendBuiltInString(rep, "package", pkg)
+		}
+	}
 	key = "msg"
-	msg := r.Message()
-	if rep == nil {
-		state.appendKey(key)
-		state.appendString(msg)
-	} else {
-		state.appendAttr(String(key, msg))
+	if h.msgKey != "" {
+		key = h.msgKey
+	}
+	state.appendBuiltInString(rep, key, r.Message())
+	// environment
+	if h.opts.Environment != "" {
+		state.appendBuiltInString(rep, "env", h.opts.Environment)
+	}
+	if h.runID != "" {
+		state.appendBuiltInString(rep, "run_id", h.runID)
+	}
+	if h.opts.AddGroupPath && len(h.groups) > 0 {
+		state.appendBuiltInString(rep, "group_path", strings.TrimSuffix(h.groupPrefix, "."))
 	}
+	for _, a := range h.resource {
+		state.appendAttr(a)
+	}
+	if h.contextBuiltIns != nil {
+		for _, a := range h.contextBuiltIns(r.Context()) {
+			state.appendAttr(a)
+		}
+	}
+	// The remaining Attrs are not built in.
+	state.nonBuiltIn = true
 	// preformatted Attrs
 	if len(h.preformattedAttrs) > 0 {
 		state.appendSep()
 		state.buf.Write(h.preformattedAttrs)
 	}
+	state.nOpen = h.nOpenGroups
+	// Attrs from With that were not preformatted. As for those that were,
+	// their error objects have no stacks, which would be those of Handle's
+	// caller, not of where the errors occurred.
+	state.setOrigin("with")
+	errorStacks := state.errorStacks
+	state.errorStacks = false
+	for i, a := range h.attrs {
+		for state.depth < len(h.groupAttrStarts) && h.groupAttrStarts[state.depth] <= i {
+			state.setDepth(state.depth + 1)
+		}
+		state.appendNonBuiltIn(a)
+	}
+	state.errorStacks = errorStacks
+	// The remaining Attrs are in all the groups.
+	state.setDepth(len(h.groups))
+	// Attrs from the context
+	state.setOrigin("context")
+	if len(h.opts.ContextExtractors) > 0 {
+		ctx := r.Context()
+		for _, ex := range h.opts.ContextExtractors {
+			for _, a := range ex(ctx) {
+				state.appendNonBuiltIn(a)
+			}
+		}
+	}
+	// context cancellation
+	if h.opts.AddContextCause {
+		if ctx := r.Context(); ctx.Err() != nil {
+			state.appendNonBuiltIn(String("ctx_err", ctx.Err().Error()))
+			state.appendNonBuiltIn(String("ctx_cause", contextCause(ctx).Error()))
+		}
+	}
 	// Attrs in Record
-	r.Attrs(func(a Attr) {
-		state.appendAttr(a)
+	state.setOrigin("call")
+	r.Attrs(func(a Attr) bool {
+		state.appendNonBuiltIn(a)
+		return true
 	})
+	if h.autoNest() {
+		state.appendNested()
+	}
+	state.closeHandlerGroups()
+	state.origin = ""
+	if len(state.provenance) > 0 {
+		state.appendProvenance()
+	}
+	state.orderTextFields()
 	h.app.appendEnd(state.buf)
-	state.buf.WriteByte('\n')
+	if h.json && len(h.opts.FieldOrder) > 0 && !h.opts.CanonicalJSON {
+		start := 0
+		if h.opts.LengthPrefixed {
+			start = frameHeaderLen
+		}
+		if err := state.orderJSONFields(start); err != nil {
+			return err
+		}
+	}
+	if h.json && h.opts.CanonicalJSON {
+		start := 0
+		if h.opts.LengthPrefixed {
+			start = frameHeaderLen
+		}
+		if err := state.canonicalize(start); err != nil {
+			return err
+		}
+	}
+	if h.json && h.opts.Indent != "" {
+		start := 0
+		if h.opts.LengthPrefixed {
+			start = frameHeaderLen
+		}
+		if err := state.indent(start); err != nil {
+			return err
+		}
+	}
+	if color != "" {
+		state.buf.WriteString(ansiReset)
+	}
+	if h.opts.LengthPrefixed {
+		putFrameHeader(*state.buf)
+	} else {
+		state.buf.WriteByte('\n')
+	}
 
+	w := h.w
+	if h.opts.WriterFunc != nil {
+		if rw := h.opts.WriterFunc(r); rw != nil {
+			w = rw
+		}
+	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	_, err := h.w.Write(*state.buf)
+	_, err := w.Write(*state.buf)
+	if err == nil && slices.Contains(h.opts.SyncLevels, r.Level()) {
+		err = syncWriter(w)
+	}
 	return err
 }
 
+// syncWriter flushes w if it buffers records, and then calls the Sync
+// method of the underlying writer, if it has one.
+func syncWriter(w io.Writer) error {
+	if bw, ok := w.(*bufferedWriter); ok {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		w = bw.w
+	}
+	if s, ok := w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// funcPackage returns the import path of the package of the function
+// with the given fully qualified name, as reported by runtime.Frame.Function.
+func funcPackage(fn string) string {
+	// The name consists of the package path, a dot, and the name of
+	// the function within the package, which may itself contain dots.
+	// The last element of the path cannot contain a dot in a valid name,
+	// but the earlier ones can.
+	slash := strings.LastIndexByte(fn, '/')
+	dot := strings.IndexByte(fn[slash+1:], '.')
+	if dot < 0 {
+		return ""
+	}
+	return fn[:slash+1+dot]
+}
+
 // handleState holds state for a single call to commonHandler.handle.
 // The initial value of sep determines whether to emit a separator
 // before the next key, after which it stays true.
@@ -207,6 +1012,32 @@ type handleState struct {
 	h   *commonHandler
 	buf *buffer.Buffer
 	sep bool // Append separator before next Attr?
+
+	errorStacks bool // include stacks in error objects
+
+	reps *replacements // if non-nil, shared with other handlers
+
+	keys       []string // keys to output, if filterKeys is true
+	filterKeys bool
+
+	nested []Attr // attrs to output at the end, if the handler nests keys
+
+	prefix string   // qualifies keys of Attrs in groups, for text output
+	groups []string // names of the groups containing the next Attr
+
+	nonBuiltIn bool // the Attrs being appended are not built in
+
+	// For the groups of the handler, from WithGroup.
+	depth  int          // number of the handler's groups the next Attr is in
+	nOpen  int          // number of the handler's groups open in buf, for JSON
+	opened []groupStart // the groups opened by this handleState, innermost last
+
+	// For HandlerOptions.AttrProvenance.
+	origin        string   // where the Attrs being appended come from
+	provenance    []Attr   // the origins of the Attrs appended so far
+	nestedOrigins []string // the origins of nested, in order
+
+	fields []fieldMark // for HandlerOptions.FieldOrder, in text output
 }
 
 // appendAttr appends the Attr's key and value using app.
@@ -215,16 +1046,280 @@ type handleState struct {
 // It sets sep to true if it actually did the append (if the key was non-empty
 // after replacement).
 func (s *handleState) appendAttr(a Attr) {
-	if rep := s.h.opts.ReplaceAttr; rep != nil {
-		a = rep(a)
+	a, ok := s.prepare(a)
+	if !ok {
+		return
+	}
+	s.appendKeyValue(a)
+}
+
+// prepare applies replacement to a, and for Attrs that are not built in,
+// the NormalizeValues option. It reports whether the result should be output.
+func (s *handleState) prepare(a Attr) (Attr, bool) {
+	a, ok := s.replace(a)
+	if !ok || !s.nonBuiltIn {
+		return a, ok
+	}
+	if a.Kind() == StringKind {
+		a = s.h.opts.NormalizeValues.normalize(a)
+		if s.h.opts.MaskSecrets {
+			if v := maskSecrets(a.str()); v != a.str() {
+				a = String(a.Key(), v)
+			}
+		}
+	}
+	if s.h.opts.BoolsAsFlags && a.Kind() == BoolKind && !a.Bool() {
+		return a, false
+	}
+	return a, !(s.h.opts.OmitEmpty && a.isEmpty())
+}
+
+// appendKeyValue appends an Attr to which replacement has been applied.
+func (s *handleState) appendKeyValue(a Attr) {
+	if a.Kind() == GroupKind {
+		s.appendGroup(a.Key(), a.group())
+		return
 	}
-	if a.Key() == "" {
+	if s.nonBuiltIn && s.h.opts.BoolsAsFlags && a.Kind() == BoolKind && !s.h.json {
+		s.appendFlag(a.Key())
 		return
 	}
 	s.appendKey(a.Key())
 	s.appendAttrValue(a)
 }
 
+// appendGroup appends the Attrs of a group: as a nested object for JSON,
+// and with keys prefixed by the group's key for text.
+// Empty groups are not output.
+func (s *handleState) appendGroup(key string, as []Attr) {
+	if len(as) == 0 {
+		return
+	}
+	start, sep := len(*s.buf), s.sep
+	prefix, origin := s.openGroup(key), s.origin
+	s.origin = "" // provenance is only tracked for top-level Attrs
+	for _, a := range as {
+		s.appendAttr(a)
+	}
+	s.origin = origin
+	if s.h.app.nestsGroups() && s.nonBuiltIn && !s.sep {
+		// All the Attrs were omitted, or were empty groups themselves,
+		// so omit the group too.
+		*s.buf = (*s.buf)[:start]
+		s.sep = sep
+		s.prefix = prefix
+		s.groups = s.groups[:len(s.groups)-1]
+		return
+	}
+	s.closeGroup(prefix)
+}
+
+// A groupStart records where a group of the handler was opened in the buffer.
+type groupStart struct {
+	offset int  // of the group's key
+	sep    bool // value of sep before the group
+}
+
+// setDepth makes the following Attrs belong to the first d groups of the
+// handler.
+func (s *handleState) setDepth(d int) {
+	s.depth = d
+	// Clip, so that Group attributes never write to the handler's groups.
+	s.groups = s.h.groups[:d:d]
+	if !s.h.app.nestsGroups() {
+		n := 0
+		for _, g := range s.h.groups[:d] {
+			n += len(g) + 1
+		}
+		s.prefix = s.h.groupPrefix[:n]
+	}
+}
+
+// openHandlerGroups opens the groups of the handler that the next Attr is
+// in, if they are not open already. It is called just before appending an
+// Attr, so that empty groups are less likely to be output.
+func (s *handleState) openHandlerGroups() {
+	for s.nOpen < s.depth && s.h.app.nestsGroups() {
+		s.opened = append(s.opened, groupStart{len(*s.buf), s.sep})
+		s.appendSep()
+		s.h.app.appendOpenGroup(s.buf, s.h.groups[s.nOpen])
+		s.sep = false
+		s.nOpen++
+	}
+}
+
+// trimHandlerGroups removes the innermost groups opened by s that are empty,
+// because the Attrs they were opened for were not output after all.
+func (s *handleState) trimHandlerGroups() {
+	for len(s.opened) > 0 && !s.sep {
+		g := s.opened[len(s.opened)-1]
+		*s.buf = (*s.buf)[:g.offset]
+		s.sep = g.sep
+		s.opened = s.opened[:len(s.opened)-1]
+		s.nOpen--
+	}
+}
+
+// closeHandlerGroups ends the groups of the handler that are open,
+// removing the empty ones.
+func (s *handleState) closeHandlerGroups() {
+	s.trimHandlerGroups()
+	if s.nOpen > 0 {
+		for ; s.nOpen > 0; s.nOpen-- {
+			s.h.app.appendCloseGroup(s.buf)
+		}
+		s.sep = true
+	}
+	s.opened = s.opened[:0]
+	s.setDepth(0)
+}
+
+// openGroup starts a group with the given key, and returns the key prefix
+// to pass to closeGroup.
+func (s *handleState) openGroup(key string) (prefix string) {
+	prefix = s.prefix
+	s.groups = append(s.groups, key)
+	if s.h.app.nestsGroups() {
+		s.appendSep()
+		s.h.app.appendOpenGroup(s.buf, key)
+		s.sep = false
+	} else {
+		s.prefix += key + "."
+	}
+	return prefix
+}
+
+// closeGroup ends the group started by the call to openGroup that returned
+// prefix.
+func (s *handleState) closeGroup(prefix string) {
+	if s.h.app.nestsGroups() {
+		s.h.app.appendCloseGroup(s.buf)
+		s.sep = true
+	}
+	s.prefix = prefix
+	s.groups = s.groups[:len(s.groups)-1]
+}
+
+// replace resolves a if its value is a LogValuer, and applies ReplaceAttr
+// and the Transforms to the result.
+// It reports whether the result should be output.
+func (s *handleState) replace(a Attr) (Attr, bool) {
+	if s.origin == "" {
+		return s.replaceAttr(a)
+	}
+	r, ok := s.replaceAttr(a)
+	s.addProvenance(a, r, ok)
+	return r, ok
+}
+
+func (s *handleState) replaceAttr(a Attr) (Attr, bool) {
+	if s.reps != nil {
+		return s.reps.replace(a, s.replaceAttrOnce)
+	}
+	if !s.h.replaces() {
+		// Fast path: there is nothing to replace, only LogValuers to resolve.
+		if a.Kind() == AnyKind {
+			a = a.resolve()
+		}
+		return a, a.Key() != ""
+	}
+	return s.replaceAttrOnce(a)
+}
+
+func (s *handleState) replaceAttrOnce(a Attr) (Attr, bool) {
+	a = a.resolve()
+	if rep := s.h.opts.ReplaceAttr; rep != nil {
+		a = rep(s.groups, a)
+	}
+	for _, t := range s.h.opts.Transforms {
+		var ok bool
+		if a, ok = t(s.groups, a); !ok {
+			return a, false
+		}
+	}
+	return a, a.Key() != ""
+}
+
+// appendBuiltInString appends a built-in attribute with a string value.
+// If rep is false, it avoids constructing an Attr.
+func (s *handleState) appendBuiltInString(rep bool, key, val string) {
+	if !rep {
+		s.appendKey(key)
+		s.appendString(val)
+	} else {
+		s.appendAttr(String(key, val))
+	}
+}
+
+// appendNonBuiltIn appends an Attr that was not created by the handler,
+// after checking that it should be output.
+func (s *handleState) appendNonBuiltIn(a Attr) {
+	if key, ok := s.h.opts.KeyAliases[a.Key()]; ok {
+		a.key = key
+	}
+	if s.filterKeys && !slices.Contains(s.keys, a.Key()) {
+		return
+	}
+	if l, ok := s.h.opts.KeyMinLevel[a.Key()]; ok && l < s.h.minLevel() {
+		return
+	}
+	// The checks above look only at the key, which resolving a LogValuer
+	// does not change, so values are not computed for Attrs they remove.
+	// Those below look at the resolved value.
+	a = s.resolve(a)
+	if s.h.opts.ErrorObjects && a.Kind() == AnyKind {
+		if err, ok := a.any.(error); ok && !isNil(err) {
+			a = s.errorObject(a.Key(), err)
+		}
+	}
+	if tv, ok := a.any.(ttlValue); ok && a.Kind() == AnyKind {
+		tv.attr.key = a.key // which may be canonical
+		s.appendNonBuiltInAttr(tv.attr)
+		s.appendNonBuiltInAttr(Duration(a.key+ttlSuffix, tv.ttl))
+		return
+	}
+	s.appendNonBuiltInAttr(a)
+}
+
+// resolve returns a with its value resolved, if it is a LogValuer. Handlers
+// sharing s.reps share the result.
+func (s *handleState) resolve(a Attr) Attr {
+	if a.Kind() != AnyKind {
+		return a
+	}
+	if _, ok := a.any.(LogValuer); !ok {
+		return a
+	}
+	if s.reps != nil {
+		a, _ = s.reps.replace(a, func(a Attr) (Attr, bool) { return a.resolve(), true })
+		return a
+	}
+	return a.resolve()
+}
+
+func (s *handleState) appendNonBuiltInAttr(a Attr) {
+	if s.h.autoNest() {
+		s.nested = append(s.nested, a)
+		if s.origin != "" {
+			s.nestedOrigins = append(s.nestedOrigins, s.origin)
+		}
+		return
+	}
+	s.openHandlerGroups()
+	a, ok := s.prepare(a)
+	if !ok {
+		return
+	}
+	s.appendKeyValue(a)
+	// Check the Attr as output, after replacement.
+	if checkSchemas {
+		if m := schemaMismatch(a); m != "" {
+			s.appendKey(schemaKey)
+			s.appendString(m)
+		}
+	}
+}
+
 func (s *handleState) appendError(err error) {
 	s.appendString(fmt.Sprintf("!ERROR:%v", err))
 }
@@ -233,6 +1328,9 @@ type appender interface {
 	appendStart(*buffer.Buffer)                 // start of output
 	appendEnd(*buffer.Buffer)                   // end of output
 	appendKey(*buffer.Buffer, string)           // append key and key-value separator
+	nestsGroups() bool                          // output groups as nested structures, not key prefixes
+	appendOpenGroup(*buffer.Buffer, string)     // start a group with a key, if nestsGroups
+	appendCloseGroup(*buffer.Buffer)            // end a group, if nestsGroups
 	appendString(*buffer.Buffer, string)        // append a string
 	appendSource(*buffer.Buffer, string, int)   // append a filename and line
 	appendTime(*buffer.Buffer, time.Time) error // append a time
@@ -246,16 +1344,34 @@ func (s *handleState) appendSep() {
 }
 
 func (s *handleState) appendKey(key string) {
+	if s.prefix != "" {
+		key = s.prefix + key
+	}
+	s.markField(key)
 	s.appendSep()
 	s.h.app.appendKey(s.buf, key)
 	s.sep = true
 }
 
+// appendFlag appends a key without a value, for HandlerOptions.BoolsAsFlags.
+func (s *handleState) appendFlag(key string) {
+	if s.prefix != "" {
+		key = s.prefix + key
+	}
+	s.markField(key)
+	s.appendSep()
+	s.h.app.appendString(s.buf, key)
+	s.sep = true
+}
+
 func (s *handleState) appendString(str string) {
 	s.h.app.appendString(s.buf, str)
 }
 
 func (s *handleState) appendAttrValue(a Attr) {
+	if max := s.h.opts.MaxAnyDepth; max > 0 {
+		a = limitDepth(a, max)
+	}
 	if err := s.h.app.appendAttrValue(s.buf, a); err != nil {
 		s.appendError(err)
 	}
@@ -7,10 +7,19 @@
 package slog
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
 func TestDefaultWith(t *testing.T) {
@@ -32,14 +41,14 @@ func TestDefaultWith(t *testing.T) {
 
 // Verify the common parts of TextHandler and JSONHandler.
 func TestJSONAndTextHandlers(t *testing.T) {
-	removeAttr := func(a Attr) Attr { return Attr{} }
+	removeAttr := func(_ []string, a Attr) Attr { return Attr{} }
 
 	attrs := []Attr{String("a", "one"), Int("b", 2), Any("", "ignore me")}
 	preAttrs := []Attr{Int("pre", 3), String("x", "y")}
 
 	for _, test := range []struct {
 		name     string
-		replace  func(Attr) Attr
+		replace  func([]string, Attr) Attr
 		preAttrs []Attr
 		attrs    []Attr
 		wantText string
@@ -118,7 +127,7 @@ func TestJSONAndTextHandlers(t *testing.T) {
 	}
 }
 
-func upperCaseKey(a Attr) Attr {
+func upperCaseKey(groups []string, a Attr) Attr {
 	return a.WithKey(strings.ToUpper(a.Key()))
 }
 
@@ -148,6 +157,50 @@ func TestHandlerEnabled(t *testing.T) {
 	}
 }
 
+func TestHandlerLevelBand(t *testing.T) {
+	const fatalLevel = ErrorLevel + 4 // hypothetical, routed elsewhere
+	var max LevelVar
+	max.Set(ErrorLevel)
+	h := &commonHandler{opts: HandlerOptions{
+		Level:    ErrorLevel, // overridden by MinLevel
+		MinLevel: WarnLevel,
+		MaxLevel: &max,
+	}}
+	for _, test := range []struct {
+		level Level
+		want  bool
+	}{
+		{DebugLevel, false},
+		{InfoLevel, false},
+		{WarnLevel, true},
+		{ErrorLevel, true},
+		{ErrorLevel + 1, false},
+		{fatalLevel, false},
+	} {
+		if got := h.Enabled(test.level); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.level, got, test.want)
+		}
+	}
+	max.Set(fatalLevel)
+	if !h.Enabled(fatalLevel) {
+		t.Errorf("%s: got false after raising MaxLevel", fatalLevel)
+	}
+
+	// Only the floor decides which keys KeyMinLevel outputs.
+	var buf bytes.Buffer
+	l := New(HandlerOptions{
+		MinLevel:    WarnLevel,
+		MaxLevel:    WarnLevel,
+		KeyMinLevel: map[string]Level{"trace": DebugLevel, "detail": ErrorLevel},
+	}.NewTextHandler(&buf))
+	l.LogAt(time.Time{}, InfoLevel, "info")
+	l.LogAt(time.Time{}, WarnLevel, "warn", "trace", 1, "detail", 2)
+	l.LogAt(time.Time{}, ErrorLevel, "error")
+	if got, want := buf.String(), "level=WARN msg=warn detail=2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 const rfc3339Millis = "2006-01-02T15:04:05.000Z07:00"
 
 func TestAppendTimeRFC3339(t *testing.T) {
@@ -175,3 +228,810 @@ func BenchmarkAppendTime(b *testing.B) {
 		buf = buf[:0]
 	}
 }
+
+func TestHandlerLevelKeySets(t *testing.T) {
+	var buf bytes.Buffer
+	opts := HandlerOptions{
+		Level: DebugLevel,
+		LevelKeySets: map[Level][]string{
+			InfoLevel: {"a", "c"},
+		},
+	}
+	h := opts.NewTextHandler(&buf).With([]Attr{Int("a", 1), Int("b", 2)})
+	for _, test := range []struct {
+		level Level
+		want  string
+	}{
+		{DebugLevel, "level=DEBUG msg=m a=1 b=2 c=3 d=4"},
+		{InfoLevel, "level=INFO msg=m a=1 c=3"},
+	} {
+		buf.Reset()
+		r := NewRecord(time.Time{}, test.level, "m", 0)
+		r.AddAttrs(Int("c", 3), Int("d", 4))
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.level, got, test.want)
+		}
+	}
+}
+
+func TestHandlerKeyMinLevel(t *testing.T) {
+	var buf bytes.Buffer
+	var level AtomicLevel
+	opts := HandlerOptions{
+		Level:       &level,
+		KeyMinLevel: map[string]Level{"payload": DebugLevel},
+	}
+	h := opts.NewTextHandler(&buf).With([]Attr{String("payload", "w"), Int("a", 1)})
+	for _, test := range []struct {
+		handlerLevel Level
+		want         string
+	}{
+		{DebugLevel, "level=INFO msg=m payload=w a=1 payload=r b=2"},
+		{InfoLevel, "level=INFO msg=m a=1 b=2"},
+		{DebugLevel, "level=INFO msg=m payload=w a=1 payload=r b=2"},
+	} {
+		level.Set(test.handlerLevel)
+		buf.Reset()
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(String("payload", "r"), Int("b", 2))
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.handlerLevel, got, test.want)
+		}
+	}
+}
+
+func TestHandlerKeyAliases(t *testing.T) {
+	// ReplaceAttr sees canonical keys.
+	opts := HandlerOptions{
+		KeyAliases: map[string]string{"uid": "user_id", "msg": "message"},
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key() == "user_id" {
+				a.key = "USER_ID"
+			}
+			return a
+		},
+	}
+	for _, test := range []struct {
+		name string
+		h    Handler
+		want string
+	}{
+		{"text", opts.NewTextHandler(io.Discard), "level=INFO msg=m USER_ID=1 g.uid=2 USER_ID=3 user_id_ttl=1s"},
+		{"json", opts.NewJSONHandler(io.Discard), `{"level":"INFO","msg":"m","USER_ID":1,"g":{"uid":2},"USER_ID":3,"user_id_ttl":1000000000}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := test.h.(interface{ WithWriter(io.Writer) Handler }).WithWriter(&buf)
+			h = h.With([]Attr{Int("uid", 1)})
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(Group("g", Int("uid", 2)), TTL(Int("uid", 3), time.Second))
+			if err := h.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHandlerFieldOrder(t *testing.T) {
+	order := []string{"msg", "b", "g", "g.c", "level", "missing"}
+	for _, test := range []struct {
+		name string
+		h    func(io.Writer) Handler
+		want string
+	}{
+		{
+			"json",
+			func(w io.Writer) Handler { return HandlerOptions{FieldOrder: order}.NewJSONHandler(w) },
+			`{"msg":"m","b":2,"g":{"c":3,"d":4},"level":"INFO","time":"2000-01-02T03:04:05Z","a":1,"e":5}`,
+		},
+		{
+			"text",
+			func(w io.Writer) Handler { return HandlerOptions{FieldOrder: order}.NewTextHandler(w) },
+			`msg=m b=2 g.c=3 level=INFO time=2000-01-02T03:04:05.000Z a=1 g.d=4 e=5`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := New(test.h(&buf)).With("a", 1, "b", 2)
+			l.LogAt(testTime, InfoLevel, "m", Group("g", Int("c", 3), Int("d", 4)), "e", 5)
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+
+	// Groups of the handler are single fields in JSON.
+	var buf bytes.Buffer
+	h := HandlerOptions{FieldOrder: []string{"s", "msg"}}.NewJSONHandler(&buf)
+	New(h).WithGroup("s").With("x", 1).LogAt(time.Time{}, InfoLevel, "m", "y", 2)
+	if got, want := buf.String(), `{"s":{"x":1,"y":2},"msg":"m","level":"INFO"}`+"\n"; got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestHandlerNoReplaceAttrAlloc(t *testing.T) {
+	// With no ReplaceAttr, handling a record must not allocate.
+	r := NewRecord(testTime, InfoLevel, "m", 0)
+	r.AddAttrs(String("s", "x"), Int("i", 1), Float64("f", 1.5), Bool("b", true),
+		Duration("d", time.Second), Time("t", testTime))
+	for _, h := range []Handler{
+		NewJSONHandler(io.Discard).With([]Attr{String("w", "y")}),
+		NewTextHandler(io.Discard).With([]Attr{String("w", "y")}),
+	} {
+		h.Handle(r)
+		wantAllocs(t, 0, func() { h.Handle(r) })
+	}
+}
+
+func TestHandlerBoolsAsFlags(t *testing.T) {
+	opts := HandlerOptions{
+		BoolsAsFlags: true,
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key() == "cleared" {
+				return Bool(a.Key(), false)
+			}
+			return a
+		},
+	}
+	attrs := []Attr{Bool("on", true), Bool("off", false), Bool("cleared", true), Int("n", 1),
+		Group("g", Bool("x", true), Bool("y", false)), Group("h", Bool("z", false))}
+	for _, test := range []struct {
+		name string
+		h    func(io.Writer) Handler
+		want string
+	}{
+		{
+			"text",
+			func(w io.Writer) Handler { return opts.NewTextHandler(w) },
+			`level=INFO msg=m w on n=1 g.x on n=1 g.x`,
+		},
+		{
+			"json",
+			func(w io.Writer) Handler { return opts.NewJSONHandler(w) },
+			`{"level":"INFO","msg":"m","w":true,"on":true,"n":1,"g":{"x":true},"on":true,"n":1,"g":{"x":true}}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := test.h(&buf).With([]Attr{Bool("w", true)}).With(attrs)
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(attrs...)
+			if err := h.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHandlerAddGroupPath(t *testing.T) {
+	for _, json := range []bool{true, false} {
+		var buf bytes.Buffer
+		opts := HandlerOptions{AddGroupPath: true}
+		var h Handler = opts.NewTextHandler(&buf)
+		want := "level=INFO msg=m x=0\n" +
+			"level=INFO msg=m group_path=a.b a.y=1 a.b.z=2\n"
+		if json {
+			h = opts.NewJSONHandler(&buf)
+			want = `{"level":"INFO","msg":"m","x":0}` + "\n" +
+				`{"level":"INFO","msg":"m","group_path":"a.b","a":{"y":1,"b":{"z":2}}}` + "\n"
+		}
+		l := New(h)
+		l.LogAt(time.Time{}, InfoLevel, "m", "x", 0)
+		l.WithGroup("a").With("y", 1).WithGroup("b").LogAt(time.Time{}, InfoLevel, "m", "z", 2)
+		if got := buf.String(); got != want {
+			t.Errorf("json=%t:\ngot\n%s\nwant\n%s", json, got, want)
+		}
+	}
+}
+
+func TestHandlerOmitEmpty(t *testing.T) {
+	opts := HandlerOptions{
+		OmitEmpty: true,
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key() == "emptied" {
+				return String(a.Key(), "")
+			}
+			return a
+		},
+	}
+	empty := []Attr{
+		String("s", ""),
+		Int("i", 0),
+		Uint64("u", 0),
+		Float64("f", 0),
+		Bool("b", false),
+		Time("t", time.Time{}),
+		Duration("d", 0),
+		Any("a", nil),
+		Any("p", (*int)(nil)),
+		String("emptied", "x"),
+		Group("g"),
+		Group("h", Int("i", 0), Group("j", String("s", ""))),
+	}
+	nonEmpty := []Attr{
+		String("s", "x"),
+		Int("i", 1),
+		Bool("b", true),
+		ExplicitNull("n"),
+		Group("h", Int("i", 0), Group("j", String("s", "y"))),
+	}
+	r := NewRecord(time.Time{}, InfoLevel, "", 0)
+	r.AddAttrs(empty...)
+	r.AddAttrs(nonEmpty...)
+	for _, test := range []struct {
+		name string
+		h    func(io.Writer) Handler
+		want string
+	}{
+		{
+			"json",
+			func(w io.Writer) Handler { return opts.NewJSONHandler(w) },
+			`{"level":"INFO","msg":"","s":"x","i":1,"b":true,"n":null,"h":{"j":{"s":"y"}},` +
+				`"s":"x","i":1,"b":true,"n":null,"h":{"j":{"s":"y"}}}`,
+		},
+		{
+			"text",
+			func(w io.Writer) Handler { return opts.NewTextHandler(w) },
+			`level=INFO msg= s=x i=1 b=true n=null h.j.s=y s=x i=1 b=true n=null h.j.s=y`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			// Both the Attrs from With and those of the record are omitted.
+			h := test.h(&buf).With(empty).With(nonEmpty)
+			if err := h.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHandlerWithGroup(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		with     func(Handler) Handler
+		attrs    []Attr
+		wantJSON string
+		wantText string
+	}{
+		{
+			"With after",
+			func(h Handler) Handler { return h.WithGroup("g").With([]Attr{Int("a", 1)}) },
+			[]Attr{Int("b", 2)},
+			`"g":{"a":1,"b":2}`,
+			`g.a=1 g.b=2`,
+		},
+		{
+			"nested",
+			func(h Handler) Handler {
+				return h.With([]Attr{Int("a", 1)}).WithGroup("g").WithGroup("h").With([]Attr{Int("b", 2)})
+			},
+			[]Attr{Int("c", 3)},
+			`"a":1,"g":{"h":{"b":2,"c":3}}`,
+			`a=1 g.h.b=2 g.h.c=3`,
+		},
+		{
+			"between",
+			func(h Handler) Handler {
+				return h.WithGroup("g").With([]Attr{Int("a", 1)}).WithGroup("h").With([]Attr{Int("b", 2)})
+			},
+			[]Attr{Group("i", Int("c", 3))},
+			`"g":{"a":1,"h":{"b":2,"i":{"c":3}}}`,
+			`g.a=1 g.h.b=2 g.h.i.c=3`,
+		},
+		{
+			"empty",
+			func(h Handler) Handler { return h.With([]Attr{Int("a", 1)}).WithGroup("g") },
+			nil,
+			`"a":1`,
+			`a=1`,
+		},
+		{
+			"empty inner",
+			func(h Handler) Handler { return h.WithGroup("g").With([]Attr{Int("a", 1)}).WithGroup("h") },
+			nil,
+			`"g":{"a":1}`,
+			`g.a=1`,
+		},
+		{
+			"all replaced",
+			func(h Handler) Handler { return h.WithGroup("g").With([]Attr{Int("drop", 1)}).WithGroup("h") },
+			[]Attr{Int("drop", 2)},
+			``,
+			``,
+		},
+		{
+			"empty record group",
+			func(h Handler) Handler { return h.WithGroup("g") },
+			[]Attr{Group("e", Group("f")), Group("i", Int("drop", 1))},
+			``,
+			``,
+		},
+		{
+			"empty With group",
+			func(h Handler) Handler {
+				return h.WithGroup("g").With([]Attr{Group("e", Group("f"), Group("i", Int("drop", 1)))})
+			},
+			[]Attr{Int("b", 2)},
+			`"g":{"b":2}`,
+			`g.b=2`,
+		},
+		{
+			"empty name",
+			func(h Handler) Handler { return h.WithGroup("").With([]Attr{Int("a", 1)}) },
+			[]Attr{Int("b", 2)},
+			`"a":1,"b":2`,
+			`a=1 b=2`,
+		},
+	} {
+		for _, preformat := range []bool{true, false} {
+			opts := HandlerOptions{
+				ReplaceAttr: func(groups []string, a Attr) Attr {
+					if a.Key() == "drop" {
+						return Attr{}
+					}
+					return a
+				},
+			}
+			if !preformat {
+				opts.KeyMinLevel = map[string]Level{"other": DebugLevel}
+			}
+			for _, json := range []bool{true, false} {
+				var buf bytes.Buffer
+				var h Handler
+				want := `level=INFO msg=m`
+				if json {
+					h = opts.NewJSONHandler(&buf)
+					want = `{"level":"INFO","msg":"m"`
+					if test.wantJSON != "" {
+						want += "," + test.wantJSON
+					}
+					want += "}"
+				} else {
+					h = opts.NewTextHandler(&buf)
+					if test.wantText != "" {
+						want += " " + test.wantText
+					}
+				}
+				r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+				r.AddAttrs(test.attrs...)
+				if err := test.with(h).Handle(r); err != nil {
+					t.Fatal(err)
+				}
+				if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+					t.Errorf("%s, preformat=%t, json=%t:\ngot  %s\nwant %s", test.name, preformat, json, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestHandlerPreformatted(t *testing.T) {
+	// Output with Attrs preformatted by With matches output without.
+	as := []Attr{String("s", "a b"), Int("i", 1), Float64("f", 2.5), Bool("b", true),
+		Duration("d", time.Second), Time("t", testTime), Any("p", nil), Group("g", Int("x", 1))}
+	with := func(h Handler) Handler {
+		return h.With(as[:3]).WithGroup("grp").With(as[3:]).With([]Attr{Group("empty")})
+	}
+	uncached := HandlerOptions{KeyMinLevel: map[string]Level{"other": DebugLevel}}
+	for _, json := range []bool{true, false} {
+		var cbuf, ubuf bytes.Buffer
+		var ch, uh Handler
+		if json {
+			ch, uh = NewJSONHandler(&cbuf), uncached.NewJSONHandler(&ubuf)
+		} else {
+			ch, uh = NewTextHandler(&cbuf), uncached.NewTextHandler(&ubuf)
+		}
+		ch, uh = with(ch), with(uh)
+		var c *commonHandler
+		if json {
+			c = ch.(*JSONHandler).commonHandler
+		} else {
+			c = ch.(*TextHandler).commonHandler
+		}
+		if len(c.preformattedAttrs) == 0 || len(c.attrs) > 0 {
+			t.Fatalf("json=%t: Attrs were not preformatted", json)
+		}
+		for i := 0; i < 2; i++ {
+			r := NewRecord(testTime, InfoLevel, "m", 0)
+			r.AddAttrs(Int("n", i))
+			if err := ch.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if err := uh.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if cbuf.String() != ubuf.String() {
+			t.Errorf("json=%t:\npreformatted:\n%s\nnot preformatted:\n%s", json, cbuf.String(), ubuf.String())
+		}
+	}
+}
+
+func BenchmarkWithAttrs(b *testing.B) {
+	// With preformatting, the cost of a record barely depends on the
+	// number of Attrs passed to With.
+	for _, preformat := range []bool{true, false} {
+		for _, n := range []int{1, 10, 100} {
+			b.Run(fmt.Sprintf("preformat=%t/%d", preformat, n), func(b *testing.B) {
+				var opts HandlerOptions
+				if !preformat {
+					opts.KeyMinLevel = map[string]Level{"other": DebugLevel}
+				}
+				as := make([]Attr, n)
+				for i := range as {
+					as[i] = String(fmt.Sprintf("key%d", i), "value")
+				}
+				h := opts.NewJSONHandler(io.Discard).With(as)
+				r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+				r.AddAttrs(Int("a", 1))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					h.Handle(r)
+				}
+			})
+		}
+	}
+}
+
+func TestHandlerTransforms(t *testing.T) {
+	redact := func(_ []string, a Attr) (Attr, bool) {
+		if a.Key() == "password" {
+			return String(a.Key(), "REDACTED"), true
+		}
+		return a, true
+	}
+	rename := func(_ []string, a Attr) (Attr, bool) {
+		if a.Key() == "password" {
+			return a.WithKey("pw"), true
+		}
+		return a, true
+	}
+	drop := func(_ []string, a Attr) (Attr, bool) {
+		return a, a.Key() != "time"
+	}
+	var buf bytes.Buffer
+	opts := HandlerOptions{
+		ReplaceAttr: upperCaseKey,
+		Transforms: []func([]string, Attr) (Attr, bool){
+			func(_ []string, a Attr) (Attr, bool) {
+				return a.WithKey(strings.ToLower(a.Key())), true
+			},
+			drop, redact, rename,
+		},
+	}
+	h := opts.NewTextHandler(&buf).With([]Attr{String("password", "secret")})
+	r := NewRecord(testTime, InfoLevel, "m", 0)
+	r.AddAttrs(String("user", "u"), String("password", "hunter2"))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.TrimSuffix(buf.String(), "\n")
+	want := "level=INFO msg=m pw=REDACTED user=u pw=REDACTED"
+	if got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestHandlerReplaceAttrGroups(t *testing.T) {
+	var paths []string
+	opts := HandlerOptions{
+		// Redact passwords only in the auth group.
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			paths = append(paths, strings.Join(append(slices.Clip(groups), a.Key()), "."))
+			if a.Key() == "password" && len(groups) > 0 && groups[len(groups)-1] == "auth" {
+				return String(a.Key(), "REDACTED")
+			}
+			return a
+		},
+	}
+	for _, test := range []struct {
+		name string
+		new  func(io.Writer) Handler
+		want string
+	}{
+		{
+			"text",
+			func(w io.Writer) Handler { return opts.NewTextHandler(w) },
+			`level=INFO msg=m password=p1 req.auth.password=REDACTED req.auth.user=u req.password=p2`,
+		},
+		{
+			"JSON",
+			func(w io.Writer) Handler { return opts.NewJSONHandler(w) },
+			`{"level":"INFO","msg":"m","password":"p1","req":{"auth":{"password":"REDACTED","user":"u"},"password":"p2"}}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			paths = nil
+			var buf bytes.Buffer
+			h := test.new(&buf).With([]Attr{String("password", "p1")}).WithGroup("req")
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(Group("auth", String("password", "hunter2"), String("user", "u")), String("password", "p2"))
+			if err := h.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+			// The built-in attributes are in no group.
+			wantPaths := []string{"password", "level", "msg", "req.auth", "req.auth.password", "req.auth.user", "req.password"}
+			if !slices.Equal(paths, wantPaths) {
+				t.Errorf("got paths %q, want %q", paths, wantPaths)
+			}
+		})
+	}
+}
+
+func TestFuncPackage(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"main.main", "main"},
+		{"golang.org/x/exp/slog.TestFuncPackage", "golang.org/x/exp/slog"},
+		{"golang.org/x/exp/slog.(*Logger).Info", "golang.org/x/exp/slog"},
+		{"example.com/a.b/c.F.func1", "example.com/a.b/c"},
+	} {
+		if got := funcPackage(test.in); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestHandlerAddPackage(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(HandlerOptions{AddPackage: true}.NewTextHandler(&buf))
+	l.Info("m")
+	got := buf.String()
+	if want := " package=golang.org/x/exp/slog msg=m\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
+
+func TestHandlerZeroLevel(t *testing.T) {
+	// The zero Level is InfoLevel, and is output like it.
+	var buf bytes.Buffer
+	if err := NewTextHandler(&buf).Handle(NewRecord(time.Time{}, 0, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "level=INFO msg=m\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandlerEnvironment(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(HandlerOptions{Environment: "staging"}.NewJSONHandler(&buf)).With("a", 1)
+	l.Info("m1")
+	l.Warn("m2", "b", 2)
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		`"level":"INFO","msg":"m1","env":"staging","a":1}`,
+		`"level":"WARN","msg":"m2","env":"staging","a":1,"b":2}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if !strings.HasSuffix(got[i], want[i]) {
+			t.Errorf("got %s, want suffix %s", got[i], want[i])
+		}
+	}
+}
+
+func TestHandlerRunID(t *testing.T) {
+	runIDRE := regexp.MustCompile(`"run_id":"([0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12})"`)
+	runIDs := func(buf *bytes.Buffer) []string {
+		var ids []string
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			m := runIDRE.FindStringSubmatch(line)
+			if m == nil {
+				t.Fatalf("no run ID in %s", line)
+			}
+			ids = append(ids, m[1])
+		}
+		return ids
+	}
+
+	var buf bytes.Buffer
+	h := HandlerOptions{AddRunID: true}.NewJSONHandler(&buf)
+	l := New(h)
+	l.Info("m1")
+	l.With("a", 1).Warn("m2")
+	l.Error("m3", nil)
+	ids := runIDs(&buf)
+	if len(ids) != 3 {
+		t.Fatalf("got %d run IDs, want 3", len(ids))
+	}
+	for _, id := range ids[1:] {
+		if id != ids[0] {
+			t.Errorf("got run IDs %v, want all the same", ids)
+			break
+		}
+	}
+
+	// Another handler has another run ID.
+	var buf2 bytes.Buffer
+	New(HandlerOptions{AddRunID: true}.NewJSONHandler(&buf2)).Info("m")
+	if id := runIDs(&buf2)[0]; id == ids[0] {
+		t.Errorf("two handlers have the same run ID %s", id)
+	}
+
+	// No run ID by default.
+	buf.Reset()
+	New(NewTextHandler(&buf)).Info("m")
+	if strings.Contains(buf.String(), "run_id") {
+		t.Errorf("got %s, want no run ID", buf.String())
+	}
+}
+
+func TestHandlerWriterFunc(t *testing.T) {
+	var def, a, b bytes.Buffer
+	writers := map[string]io.Writer{"a": &a, "b": &b}
+	opts := HandlerOptions{
+		WriterFunc: func(r Record) io.Writer {
+			var w io.Writer
+			r.Attrs(func(attr Attr) bool {
+				if attr.Key() == "tenant" {
+					w = writers[attr.String()]
+					return false
+				}
+				return true
+			})
+			return w
+		},
+	}
+	l := New(opts.NewTextHandler(&def).With([]Attr{Int("x", 1)}))
+	l.Info("m1", "tenant", "a")
+	l.Info("m2", "tenant", "b")
+	l.Info("m3", "tenant", "c")
+	l.Info("m4")
+	l.Info("m5", "tenant", "a")
+	for _, test := range []struct {
+		buf  *bytes.Buffer
+		want string
+	}{
+		{&a, "m1 x=1 tenant=a|m5 x=1 tenant=a"},
+		{&b, "m2 x=1 tenant=b"},
+		{&def, "m3 x=1 tenant=c|m4 x=1"},
+	} {
+		var msgs []string
+		for _, line := range strings.Split(strings.TrimSuffix(test.buf.String(), "\n"), "\n") {
+			_, rest, _ := strings.Cut(line, "msg=")
+			msgs = append(msgs, rest)
+		}
+		if got := strings.Join(msgs, "|"); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestHandlerTimeEpoch(t *testing.T) {
+	tm := time.Date(2000, 1, 2, 3, 4, 5, 678_000_000, time.UTC)
+	gameEpoch := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		epoch time.Time
+		res   time.Duration
+		want  int64
+	}{
+		{time.Time{}, time.Second, 946782245},
+		{time.Time{}, time.Millisecond, 946782245678},
+		{time.Time{}, time.Hour, 262995},
+		{gameEpoch, 50 * time.Millisecond, (3*3600+4*60+5)*20 + 13},
+		{gameEpoch, time.Minute, 3*60 + 4},
+		{tm.Add(time.Nanosecond), time.Second, -1},
+		{tm.Add(time.Nanosecond), time.Millisecond, -1},
+		{time.Date(1, 1, 1, 0, 0, 1, 0, time.UTC), 24 * time.Hour, 730120},
+	} {
+		for _, rep := range []func([]string, Attr) Attr{nil, upperCaseKey} {
+			var buf bytes.Buffer
+			opts := HandlerOptions{TimeEpoch: test.epoch, TimeResolution: test.res, ReplaceAttr: rep}
+			if err := opts.NewJSONHandler(&buf).Handle(NewRecord(tm, InfoLevel, "m", 0)); err != nil {
+				t.Fatal(err)
+			}
+			key := "time"
+			if rep != nil {
+				key = "TIME"
+			}
+			want := fmt.Sprintf(`{"%s":%d,`, key, test.want)
+			if got := buf.String(); !strings.HasPrefix(got, want) {
+				t.Errorf("%s, %s: got %s, want prefix %s", test.epoch, test.res, got, want)
+			}
+		}
+	}
+}
+
+func TestHandlerPipe(t *testing.T) {
+	const goroutines, records = 4, 50
+	for _, test := range []struct {
+		name  string
+		new   func(io.Writer) Handler
+		valid func(string) bool
+	}{
+		{
+			"json",
+			func(w io.Writer) Handler { return NewJSONHandler(w) },
+			func(line string) bool { return json.Valid([]byte(line)) },
+		},
+		{
+			"text",
+			func(w io.Writer) Handler { return NewTextHandler(w) },
+			func(line string) bool {
+				return strings.HasPrefix(line, "time=") && strings.HasSuffix(line, " end=true")
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pr, pw := io.Pipe()
+			h := test.new(pw)
+			lines := make(chan []string)
+			go func() {
+				// Read slowly, so that writes block.
+				var ls []string
+				sc := bufio.NewScanner(bufio.NewReaderSize(pr, 16))
+				for sc.Scan() {
+					ls = append(ls, sc.Text())
+				}
+				lines <- ls
+			}()
+
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < records; i++ {
+						r := NewRecord(time.Now(), InfoLevel, "a message", 0)
+						r.AddAttrs(Int("g", g), Int("i", i), String("pad", strings.Repeat("x", 100)), Bool("end", true))
+						if err := h.Handle(r); err != nil {
+							t.Error(err)
+						}
+					}
+				}(g)
+			}
+			wg.Wait()
+			pw.Close()
+			ls := <-lines
+			if got, want := len(ls), goroutines*records; got != want {
+				t.Errorf("got %d records, want %d", got, want)
+			}
+			for _, l := range ls {
+				if !test.valid(l) {
+					t.Errorf("interleaved or partial record: %s", l)
+					break
+				}
+			}
+
+			// Writing to a pipe whose reader is closed fails immediately.
+			pr, pw = io.Pipe()
+			pr.Close()
+			h = test.new(pw)
+			done := make(chan error)
+			go func() { done <- h.Handle(NewRecord(time.Now(), InfoLevel, "m", 0)) }()
+			select {
+			case err := <-done:
+				if !errors.Is(err, io.ErrClosedPipe) {
+					t.Errorf("got %v, want %v", err, io.ErrClosedPipe)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("Handle hung writing to a closed pipe")
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPOptions are options for an HTTPHandler.
+type HTTPOptions struct {
+	// Client sends the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// BatchSize is the size in bytes of the buffer in which records are
+	// batched, as with [HandlerOptions.WriteBufferSize]. If it is not
+	// positive, 64 KiB is used.
+	BatchSize int
+
+	// FlushInterval is the longest time a record waits in the buffer, as
+	// with [HandlerOptions.FlushInterval]. If it is not positive, one second
+	// is used.
+	FlushInterval time.Duration
+
+	// Attempts is the number of times a batch is sent before it is dropped.
+	// If it is not positive, 3 is used.
+	Attempts int
+
+	// Backoff is the time to wait before sending a batch again. It doubles
+	// after each failure. If it is not positive, 100ms is used.
+	Backoff time.Duration
+}
+
+// HTTPHandler is a Handler that sends batches of records, as line-delimited
+// JSON, in the bodies of POST requests to a URL.
+//
+// Records are batched in a buffer, which is sent when it is full, when it has
+// held a record for the flush interval, or when Flush is called. Batches are
+// sent by a goroutine of their own, so that handling continues meanwhile.
+// A request fails if it returns an error or a response whose status code is
+// not 2xx; it is then retried after a backoff, and after the last attempt
+// the batch is dropped and its records are counted by Dropped. While a batch
+// is being sent, one more can wait to be sent; after that, calls to Handle
+// that need the buffer wait, so that a slow endpoint applies backpressure to
+// the program rather than growing memory.
+//
+// Close sends the remaining records and stops the goroutine. It should be
+// called when the handler is no longer needed.
+type HTTPHandler struct {
+	*JSONHandler
+	poster *httpPoster
+}
+
+// NewHTTPHandler creates an HTTPHandler that posts records to url,
+// using the default options and the given HTTP options.
+func NewHTTPHandler(url string, ho HTTPOptions) *HTTPHandler {
+	return (HandlerOptions{}).NewHTTPHandler(url, ho)
+}
+
+// NewHTTPHandler creates an HTTPHandler with the given options that posts
+// records to url. The WriteBufferSize and FlushInterval options are
+// replaced by those of ho.
+func (opts HandlerOptions) NewHTTPHandler(url string, ho HTTPOptions) *HTTPHandler {
+	p := &httpPoster{
+		url:      url,
+		client:   ho.Client,
+		attempts: ho.Attempts,
+		backoff:  ho.Backoff,
+		batches:  make(chan httpBatch, 1),
+		done:     make(chan struct{}),
+	}
+	if p.client == nil {
+		p.client = http.DefaultClient
+	}
+	if p.attempts <= 0 {
+		p.attempts = 3
+	}
+	if p.backoff <= 0 {
+		p.backoff = 100 * time.Millisecond
+	}
+	opts.WriteBufferSize = ho.BatchSize
+	if opts.WriteBufferSize <= 0 {
+		opts.WriteBufferSize = 64 << 10
+	}
+	opts.FlushInterval = ho.FlushInterval
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	go p.run()
+	return &HTTPHandler{JSONHandler: opts.NewJSONHandler(p), poster: p}
+}
+
+// With returns a new HTTPHandler whose attributes consists
+// of h's attributes followed by attrs.
+// The new handler shares h's buffer.
+func (h *HTTPHandler) With(attrs []Attr) Handler {
+	return &HTTPHandler{JSONHandler: h.JSONHandler.With(attrs).(*JSONHandler), poster: h.poster}
+}
+
+// WithGroup returns a new HTTPHandler whose later attributes are output in
+// a group with the given name, as with [JSONHandler.WithGroup].
+// The new handler shares h's buffer.
+func (h *HTTPHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &HTTPHandler{JSONHandler: h.JSONHandler.WithGroup(name).(*JSONHandler), poster: h.poster}
+}
+
+// Flush sends the buffered records, and waits until all the batches have
+// been sent or dropped.
+func (h *HTTPHandler) Flush() error {
+	err := h.JSONHandler.Flush()
+	h.poster.wait()
+	return err
+}
+
+// Close sends the buffered records, waits until all the batches have been
+// sent or dropped, and stops the goroutine sending them. Records handled
+// after Close are dropped. Close closes the handlers derived from h with
+// With and WithGroup as well, and calling it again has no effect.
+func (h *HTTPHandler) Close() error {
+	err := h.JSONHandler.Flush()
+	h.poster.close()
+	return err
+}
+
+// Dropped returns the number of records that were dropped because all the
+// attempts to send them failed, or because they were handled after Close.
+func (h *HTTPHandler) Dropped() int64 {
+	return atomic.LoadInt64(&h.poster.dropped)
+}
+
+// An httpPoster is the io.Writer of an HTTPHandler. Each write queues a
+// batch of records, which its run method sends.
+type httpPoster struct {
+	url      string
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+	dropped  int64 // accessed atomically
+
+	mu      sync.Mutex // held while sending on batches, and to close it
+	closed  bool
+	batches chan httpBatch
+	done    chan struct{} // closed when run returns
+}
+
+// An httpBatch is a batch of records to send, or, if flushed is non-nil, a
+// request to close flushed when the batches before it have been sent.
+type httpBatch struct {
+	b       []byte
+	flushed chan struct{}
+}
+
+// Write queues the records in b to be sent, waiting if a batch is already
+// queued. It never returns an error, which would make the buffer unusable;
+// records that cannot be sent are counted as dropped.
+func (p *httpPoster) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		p.drop(b)
+		return len(b), nil
+	}
+	// The caller reuses b.
+	p.batches <- httpBatch{b: append([]byte(nil), b...)}
+	return len(b), nil
+}
+
+// wait returns when the batches queued before it was called have been sent
+// or dropped.
+func (p *httpPoster) wait() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.done
+		return
+	}
+	flushed := make(chan struct{})
+	p.batches <- httpBatch{flushed: flushed}
+	p.mu.Unlock()
+	<-flushed
+}
+
+// close stops run after it sends the queued batches, and waits for it.
+func (p *httpPoster) close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.batches)
+	}
+	p.mu.Unlock()
+	<-p.done
+}
+
+// run sends the queued batches until the queue is closed.
+func (p *httpPoster) run() {
+	defer close(p.done)
+	for batch := range p.batches {
+		if batch.flushed != nil {
+			close(batch.flushed)
+			continue
+		}
+		p.send(batch.b)
+	}
+}
+
+// send sends the records in b, retrying on failure, and counts them as
+// dropped if all the attempts fail.
+func (p *httpPoster) send(b []byte) {
+	wait := p.backoff
+	for i := 0; i < p.attempts; i++ {
+		if i > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		if p.post(b) == nil {
+			return
+		}
+	}
+	p.drop(b)
+}
+
+// drop counts the records in b as dropped.
+func (p *httpPoster) drop(b []byte) {
+	atomic.AddInt64(&p.dropped, int64(bytes.Count(b, []byte{'\n'})))
+}
+
+// post sends b in a single request.
+func (p *httpPoster) post(b []byte) error {
+	res, err := p.client.Post(p.url, "application/x-ndjson", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	// Read the body, so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("slog: POST %s: %s", p.url, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// batchServer is an HTTP server that records the bodies of the requests it
+// receives, responding to them with the given status codes in turn, and then
+// with 200.
+type batchServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests int
+	bodies   []string // of successful requests
+}
+
+func newBatchServer(statuses ...int) *batchServer {
+	s := &batchServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		if len(s.statuses) > 0 {
+			code := s.statuses[0]
+			s.statuses = s.statuses[1:]
+			if code != http.StatusOK {
+				w.WriteHeader(code)
+				return
+			}
+		}
+		s.bodies = append(s.bodies, string(body))
+	}))
+	return s
+}
+
+func TestHTTPHandler(t *testing.T) {
+	srv := newBatchServer()
+	defer srv.Close()
+	h := NewHTTPHandler(srv.URL, HTTPOptions{BatchSize: 100, FlushInterval: time.Hour})
+	defer h.Close()
+	l := New(h).With("a", 1)
+	const n = 10
+	for i := 0; i < n; i++ {
+		l.Info("message", "i", i)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// The records are spread over several batches, but none is split.
+	var records []map[string]any
+	for _, body := range srv.bodies {
+		for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+			var m map[string]any
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+			records = append(records, m)
+		}
+	}
+	if len(srv.bodies) < 2 {
+		t.Errorf("got %d batches, want several", len(srv.bodies))
+	}
+	if len(records) != n {
+		t.Fatalf("got %d records, want %d", len(records), n)
+	}
+	for i, m := range records {
+		if m["i"] != float64(i) || m["a"] != float64(1) {
+			t.Errorf("record %d: got %v", i, m)
+		}
+	}
+	if got := h.Dropped(); got != 0 {
+		t.Errorf("got %d dropped, want 0", got)
+	}
+}
+
+func TestHTTPHandlerBatches(t *testing.T) {
+	srv := newBatchServer()
+	defer srv.Close()
+	h := NewHTTPHandler(srv.URL, HTTPOptions{BatchSize: 1 << 10, FlushInterval: 10 * time.Millisecond})
+	defer h.Close()
+	for i := 0; i < 5; i++ {
+		if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The flush interval sends all the records in one batch.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		srv.mu.Lock()
+		bodies := srv.bodies
+		srv.mu.Unlock()
+		if len(bodies) > 0 {
+			want := strings.Repeat(`{"level":"INFO","msg":"m"}`+"\n", 5)
+			if len(bodies) != 1 || bodies[0] != want {
+				t.Errorf("got bodies %q, want one body %q", bodies, want)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no batch sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHTTPHandlerRetry(t *testing.T) {
+	srv := newBatchServer(http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer srv.Close()
+	h := NewHTTPHandler(srv.URL, HTTPOptions{Attempts: 3, Backoff: time.Millisecond})
+	defer h.Close()
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if srv.requests != 3 {
+		t.Errorf("got %d requests, want 3", srv.requests)
+	}
+	if want := []string{`{"level":"INFO","msg":"m"}` + "\n"}; !slices.Equal(srv.bodies, want) {
+		t.Errorf("got bodies %q, want %q", srv.bodies, want)
+	}
+	if got := h.Dropped(); got != 0 {
+		t.Errorf("got %d dropped, want 0", got)
+	}
+
+	// After the last attempt, the batch is dropped.
+	srv.statuses = []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusBadGateway}
+	srv.bodies = nil
+	for i := 0; i < 2; i++ {
+		if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Dropped(); got != 2 {
+		t.Errorf("got %d dropped, want 2", got)
+	}
+	if len(srv.bodies) != 0 {
+		t.Errorf("got bodies %q, want none", srv.bodies)
+	}
+	// The handler still works.
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.bodies) != 1 {
+		t.Errorf("got bodies %q, want one", srv.bodies)
+	}
+}
+
+func TestHTTPHandlerClose(t *testing.T) {
+	srv := newBatchServer()
+	defer srv.Close()
+	h := NewHTTPHandler(srv.URL, HTTPOptions{FlushInterval: time.Hour})
+	l := New(h).WithGroup("g")
+	l.LogAt(time.Time{}, InfoLevel, "m")
+	// Closing a derived handler closes h.
+	if err := l.Handler().(*HTTPHandler).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`{"level":"INFO","msg":"m"}` + "\n"}; !slices.Equal(srv.bodies, want) {
+		t.Errorf("got bodies %q, want %q", srv.bodies, want)
+	}
+	select {
+	case <-h.poster.done:
+	default:
+		t.Error("sending goroutine still running after Close")
+	}
+	// Later records are dropped.
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Dropped(); got != 1 {
+		t.Errorf("got %d dropped, want 1", got)
+	}
+}
+
+func TestHTTPHandlerSlowEndpoint(t *testing.T) {
+	// Handling continues while a batch is being sent.
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	h := NewHTTPHandler(srv.URL, HTTPOptions{FlushInterval: time.Hour})
+	handled := make(chan struct{})
+	go func() {
+		defer close(handled)
+		for i := 0; i < 2; i++ {
+			// The first batch is being sent, and the second waits.
+			if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+				t.Error(err)
+			}
+			if err := h.JSONHandler.Flush(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	select {
+	case <-handled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Handle blocked by a batch being sent")
+	}
+	close(release)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Dropped(); got != 0 {
+		t.Errorf("got %d dropped, want 0", got)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package httpslog provides Attrs describing net/http values.
+package httpslog
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// RequestKey is the key used by Request.
+const RequestKey = "request"
+
+// DefaultHeaders are the request headers logged when Options.Headers is nil.
+var DefaultHeaders = []string{"Content-Type", "Referer", "User-Agent", "X-Forwarded-For", "X-Request-Id"}
+
+// SensitiveHeaders are the request headers that are logged only when
+// Options.IncludeSensitive is set.
+var SensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Options are options for Request.
+type Options struct {
+	// Headers are the names of the request headers to log. If nil,
+	// DefaultHeaders are used.
+	Headers []string
+
+	// IncludeSensitive causes SensitiveHeaders to be logged too. Otherwise
+	// they are never logged, even if they are listed in Headers.
+	IncludeSensitive bool
+}
+
+// Request calls Options.Request with the default options.
+func Request(r *http.Request) slog.Attr {
+	return Options{}.Request(r)
+}
+
+// Request returns an Attr with key RequestKey describing r as a group with
+// the keys "method", "url", "host", "proto" and "remote_addr", and a
+// "headers" group holding the selected headers that r has. Multiple values
+// of a header are joined with ", ".
+//
+// The group is built only when a handler outputs the Attr, so it costs
+// little for records that are not logged. It reflects r at that time.
+func (opts Options) Request(r *http.Request) slog.Attr {
+	if r == nil {
+		return slog.Any(RequestKey, nil)
+	}
+	return slog.Any(RequestKey, request{r, opts})
+}
+
+type request struct {
+	r    *http.Request
+	opts Options
+}
+
+func (q request) LogValue() slog.Attr {
+	r := q.r
+	url := ""
+	if r.URL != nil {
+		url = r.URL.String()
+	}
+	as := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("url", url),
+		slog.String("host", r.Host),
+		slog.String("proto", r.Proto),
+		slog.String("remote_addr", r.RemoteAddr),
+	}
+	if hs := q.headers(); len(hs) > 0 {
+		as = append(as, slog.Group("headers", hs...))
+	}
+	return slog.Group("", as...)
+}
+
+// headers returns the selected headers of the request, in order.
+func (q request) headers() []slog.Attr {
+	names := q.opts.Headers
+	if names == nil {
+		names = DefaultHeaders
+	}
+	if q.opts.IncludeSensitive {
+		names = append(names[:len(names):len(names)], SensitiveHeaders...)
+	}
+	var as []slog.Attr
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if seen[name] || (!q.opts.IncludeSensitive && isSensitive(name)) {
+			continue
+		}
+		seen[name] = true
+		if vs := q.r.Header.Values(name); len(vs) > 0 {
+			as = append(as, slog.String(name, strings.Join(vs, ", ")))
+		}
+	}
+	return as
+}
+
+func isSensitive(name string) bool {
+	for _, s := range SensitiveHeaders {
+		if name == s {
+			return true
+		}
+	}
+	return false
+}
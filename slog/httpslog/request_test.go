@@ -0,0 +1,106 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httpslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+func TestRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/a?b=c", nil)
+	r.Header.Set("User-Agent", "test")
+	r.Header.Add("X-Forwarded-For", "1.2.3.4")
+	r.Header.Add("X-Forwarded-For", "5.6.7.8")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("Cookie", "id=secret")
+	r.Header.Set("Accept", "*/*")
+
+	for _, test := range []struct {
+		name        string
+		opts        Options
+		wantHeaders map[string]any
+	}{
+		{
+			"default", Options{},
+			map[string]any{"User-Agent": "test", "X-Forwarded-For": "1.2.3.4, 5.6.7.8"},
+		},
+		{
+			"headers", Options{Headers: []string{"accept", "Cookie"}},
+			map[string]any{"Accept": "*/*"},
+		},
+		{
+			"sensitive", Options{Headers: []string{"Accept"}, IncludeSensitive: true},
+			map[string]any{"Accept": "*/*", "Authorization": "Bearer secret", "Cookie": "id=secret"},
+		},
+		{"none", Options{Headers: []string{}}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := slog.New(slog.NewJSONHandler(&buf))
+			l.LogAt(time.Time{}, slog.InfoLevel, "m", test.opts.Request(r))
+			var got struct{ Request map[string]any }
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("%v: %s", err, buf.String())
+			}
+			var keys []string
+			for k := range got.Request {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			wantKeys := []string{"host", "method", "proto", "remote_addr", "url"}
+			if test.wantHeaders != nil {
+				wantKeys = []string{"headers", "host", "method", "proto", "remote_addr", "url"}
+			}
+			if !reflect.DeepEqual(keys, wantKeys) {
+				t.Errorf("got keys %v, want %v", keys, wantKeys)
+			}
+			if got, want := got.Request["url"], "http://example.com/a?b=c"; got != want {
+				t.Errorf("url: got %v, want %v", got, want)
+			}
+			if got, want := got.Request["method"], "GET"; got != want {
+				t.Errorf("method: got %v, want %v", got, want)
+			}
+			if test.wantHeaders != nil {
+				if got := got.Request["headers"]; !reflect.DeepEqual(got, test.wantHeaders) {
+					t.Errorf("headers: got %v, want %v", got, test.wantHeaders)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestLazy(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	a := Request(r)
+	if _, ok := a.Value().(slog.LogValuer); !ok {
+		t.Fatalf("got value of type %T, want a LogValuer", a.Value())
+	}
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf))
+	// The request is read when the record is output, not when the Attr
+	// is made.
+	r.URL = nil
+	r.Method = "POST"
+	l.LogAt(time.Time{}, slog.DebugLevel, "disabled", a)
+	if buf.Len() != 0 {
+		t.Errorf("got output for a disabled record: %q", buf.String())
+	}
+	l.LogAt(time.Time{}, slog.InfoLevel, "enabled", a)
+	want := `level=INFO msg=enabled request.method=POST request.url= request.host=example.com request.proto=HTTP/1.1 request.remote_addr=192.0.2.1:1234` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+	if got := Request(nil); got.Value() != nil {
+		t.Errorf("Request(nil): got %v, want nil value", got)
+	}
+}
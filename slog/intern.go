@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "sync"
+
+// maxInterned is the maximum number of strings an internTable holds.
+// Once it is full, strings are no longer interned, so that a stream of
+// distinct strings cannot grow it without bound.
+const maxInterned = 1024
+
+// An internTable holds a single copy of each of a set of strings.
+// It is safe for concurrent use. A nil *internTable interns nothing.
+type internTable struct {
+	mu sync.RWMutex
+	m  map[string]string
+}
+
+func newInternTable() *internTable {
+	return &internTable{m: map[string]string{}}
+}
+
+// bytes returns b as a string, reusing a previous copy if possible.
+func (t *internTable) bytes(b []byte) string {
+	if t == nil {
+		return string(b)
+	}
+	t.mu.RLock()
+	s, ok := t.m[string(b)] // does not allocate
+	t.mu.RUnlock()
+	if ok {
+		return s
+	}
+	s = string(b)
+	t.add(s)
+	return s
+}
+
+// string returns a string equal to s, reusing a previous copy if possible.
+func (t *internTable) string(s string) string {
+	if t == nil {
+		return s
+	}
+	t.mu.RLock()
+	s2, ok := t.m[s]
+	t.mu.RUnlock()
+	if ok {
+		return s2
+	}
+	t.add(s)
+	return s
+}
+
+func (t *internTable) add(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.m) < maxInterned {
+		t.m[s] = s
+	}
+}
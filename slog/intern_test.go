@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestInternTable(t *testing.T) {
+	tab := newInternTable()
+	s1 := tab.bytes([]byte("abc"))
+	s2 := tab.bytes([]byte("abc"))
+	if stringData(s1) != stringData(s2) {
+		t.Error("strings not shared")
+	}
+	if s3 := tab.string(string([]byte("abc"))); stringData(s3) != stringData(s1) {
+		t.Error("strings not shared")
+	}
+	for i := 0; len(tab.m) < maxInterned; i++ {
+		tab.string(strconv.Itoa(i))
+	}
+	tab.string("extra")
+	if got := len(tab.m); got != maxInterned {
+		t.Errorf("got %d strings, want %d", got, maxInterned)
+	}
+	var nilTab *internTable
+	if got := nilTab.bytes([]byte("x")); got != "x" {
+		t.Errorf("got %q, want x", got)
+	}
+}
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+// status is a TextMarshaler whose output is one of a small set of strings.
+type status int
+
+var statusText = [][]byte{[]byte("ok"), []byte("not_found"), []byte("error")}
+
+func (s status) MarshalText() ([]byte, error) { return statusText[s], nil }
+
+func TestInternStrings(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions{InternStrings: true, LevelKeySets: map[Level][]string{}}.NewTextHandler(&buf)
+	h2 := h.With([]Attr{String("a", "x")})
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(Any("s", status(1)))
+	for i := 0; i < 2; i++ {
+		if err := h2.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "level=INFO msg=m a=x s=not_found\nlevel=INFO msg=m a=x s=not_found\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInternStringsAlloc(t *testing.T) {
+	r := NewRecord(time.Now(), InfoLevel, "msg", 0)
+	for i := 0; i < 10; i++ {
+		r.AddAttrs(Any("status", status(i%len(statusText))))
+	}
+	h := HandlerOptions{InternStrings: true}.NewTextHandler(io.Discard)
+	h.Handle(r)
+	wantAllocs(t, 0, func() { h.Handle(r) })
+}
+
+func BenchmarkInternStrings(b *testing.B) {
+	for _, intern := range []bool{false, true} {
+		b.Run("intern="+strconv.FormatBool(intern), func(b *testing.B) {
+			opts := HandlerOptions{
+				AddSource:     true,
+				ReplaceAttr:   func(_ []string, a Attr) Attr { return a },
+				InternStrings: intern,
+			}
+			h := opts.NewTextHandler(io.Discard)
+			r := NewRecord(time.Now(), InfoLevel, "msg", 1)
+			for i := 0; i < 6; i++ {
+				r.AddAttrs(Any("status", status(i%len(statusText))))
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = h.Handle(r)
+			}
+		})
+	}
+}
@@ -24,14 +24,51 @@ func New() *Buffer {
 	return bufPool.Get().(*Buffer)
 }
 
+// To reduce peak allocation, only buffers of at most this capacity are
+// returned to a pool.
+const maxBufferSize = 16 << 10
+
 func (b *Buffer) Free() {
-	// To reduce peak allocation, return only smaller buffers to the pool.
-	const maxBufferSize = 16 << 10
 	if cap(*b) <= maxBufferSize {
 		*b = (*b)[:0]
 		bufPool.Put(b)
 	}
 }
+
+// A Pool is a pool of Buffers with an initial capacity, for records that
+// are larger than usual. Buffers of up to twice that capacity, or 16KB if
+// that is larger, are returned to the pool; larger ones are dropped.
+type Pool struct {
+	size int
+	p    sync.Pool
+}
+
+// NewPool returns a Pool of Buffers with the given initial capacity.
+func NewPool(size int) *Pool {
+	return &Pool{size: size}
+}
+
+// Get returns a Buffer from the pool.
+func (p *Pool) Get() *Buffer {
+	if b, ok := p.p.Get().(*Buffer); ok {
+		return b
+	}
+	b := make([]byte, 0, p.size)
+	return (*Buffer)(&b)
+}
+
+// Put returns b, which came from Get, to the pool, unless it is too large.
+func (p *Pool) Put(b *Buffer) {
+	max := 2 * p.size
+	if max < maxBufferSize {
+		max = maxBufferSize
+	}
+	if cap(*b) <= max {
+		*b = (*b)[:0]
+		p.p.Put(b)
+	}
+}
+
 func (b *Buffer) Write(p []byte) (int, error) {
 	*b = append(*b, p...)
 	return len(p), nil
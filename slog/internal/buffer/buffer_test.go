@@ -18,3 +18,22 @@ func Test(t *testing.T) {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
+
+func TestPool(t *testing.T) {
+	p := NewPool(64 << 10)
+	b := p.Get()
+	if got, want := cap(*b), 64<<10; got != want {
+		t.Errorf("got capacity %d, want %d", got, want)
+	}
+	b.WriteString("x")
+	p.Put(b)
+	// Buffers that grew too large are not retained.
+	big := make(Buffer, 0, 1<<20)
+	p.Put(&big)
+	for i := 0; i < 10; i++ {
+		b := p.Get()
+		if len(*b) != 0 || cap(*b) > 128<<10 {
+			t.Fatalf("got buffer with length %d and capacity %d", len(*b), cap(*b))
+		}
+	}
+}
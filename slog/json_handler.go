@@ -5,7 +5,9 @@
 package slog
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -32,10 +34,19 @@ func NewJSONHandler(w io.Writer) *JSONHandler {
 func (opts HandlerOptions) NewJSONHandler(w io.Writer) *JSONHandler {
 	return &JSONHandler{
 		&commonHandler{
-			app:     jsonAppender{},
-			attrSep: ',',
-			w:       w,
-			opts:    opts,
+			app: jsonAppender{
+				noHTMLEscape: opts.NoHTMLEscape,
+				timeFormat:   opts.TimeFormat,
+				durations:    opts.DurationFormat,
+			},
+			json:     true,
+			interns:  opts.newInternTable(),
+			attrSep:  ',',
+			bufs:     opts.newBufferPool(),
+			w:        opts.newWriter(w),
+			opts:     opts,
+			runID:    opts.newRunID(),
+			resource: opts.Resource.attrs(),
 		},
 	}
 }
@@ -46,19 +57,38 @@ func (h *JSONHandler) With(attrs []Attr) Handler {
 	return &JSONHandler{commonHandler: h.commonHandler.with(attrs)}
 }
 
+// WithGroup returns a new JSONHandler whose later attributes, whether
+// passed to With or in records, are output in a nested object with the
+// given key. The object is omitted if it would be empty.
+func (h *JSONHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &JSONHandler{commonHandler: h.commonHandler.withGroup(name)}
+}
+
+// WithWriter returns a new JSONHandler that is like h, with the same options and
+// attributes, but writes to w. It is useful for redirecting output, as when
+// reopening a log file after rotation.
+func (h *JSONHandler) WithWriter(w io.Writer) Handler {
+	return &JSONHandler{commonHandler: h.commonHandler.withWriter(w)}
+}
+
 // Handle formats its argument Record as a JSON object on a single line.
 //
 // If the Record's time is zero, the time is omitted.
 // Otherwise, the key is "time"
-// and the value is output as with json.Marshal.
+// and the value is output as with json.Marshal,
+// or as a string in [HandlerOptions.TimeFormat] if it is set.
 //
-// If the Record's level is zero, the level is omitted.
-// Otherwise, the key is "level"
+// The level's key is "level"
 // and the value of [Level.String] is output.
+// The zero level is [InfoLevel], so it is output as "INFO".
 //
 // If the AddSource option is set and source information is available,
 // the key is "source"
-// and the value is output as "FILE:LINE".
+// and the value is output as "FILE:LINE",
+// or as an object if the SourceAsGroup option is set.
 //
 // The message's key is "msg".
 //
@@ -69,41 +99,116 @@ func (h *JSONHandler) With(attrs []Attr) Handler {
 // exceptions:
 //   - Floating-point NaNs and infinities are formatted as one of the strings
 //     "NaN", "+Inf" or "-Inf".
+//   - Complex numbers are formatted as objects with members "real" and
+//     "imag".
 //   - Levels are formatted as with Level.String.
+//   - Times are formatted with [HandlerOptions.TimeFormat], if it is set.
+//   - Durations are formatted according to [HandlerOptions.DurationFormat].
+//   - Nil values, including nil pointers, maps, slices, channels and
+//     functions, are formatted as null, as are values created by
+//     [ExplicitNull].
+//   - Groups are formatted as nested objects. Empty groups are omitted.
+//   - Arrays of 16 bytes that do not marshal themselves are formatted as
+//     UUIDs, as with [UUID].
+//   - Values created by [Decimal], and values whose String method returns
+//     a number, are formatted as numbers with all their digits.
+//   - Errors that do not marshal themselves are formatted as objects
+//     describing the errors they wrap, as with [Err].
 //
-// Each call to Handle results in a single serialized call to io.Writer.Write.
+// If [HandlerOptions.Indent] is set, each record is written over several
+// lines. A record in that form may be written with more than one call to
+// io.Writer.Write, so readers should not rely on each Write holding exactly
+// one record. Otherwise, each call to Handle results in a single serialized
+// call to io.Writer.Write.
 func (h *JSONHandler) Handle(r Record) error {
 	return h.commonHandler.handle(r)
 }
 
-type jsonAppender struct{}
+// indent replaces the JSON value that follows offset start in s.buf
+// with its indented form, according to HandlerOptions.Indent.
+func (s *handleState) indent(start int) error {
+	var out bytes.Buffer
+	if err := json.Indent(&out, (*s.buf)[start:], "", s.h.opts.Indent); err != nil {
+		return err
+	}
+	*s.buf = append((*s.buf)[:start], out.Bytes()...)
+	return nil
+}
+
+type jsonAppender struct {
+	noHTMLEscape bool   // don't escape <, > and &
+	timeFormat   string // if non-empty, layout for times
+	durations    DurationFormat
+}
+
+// A DurationFormat is a way for the JSONHandler to output durations.
+// See [HandlerOptions.DurationFormat].
+type DurationFormat int
+
+const (
+	// DurationNanos outputs durations as integer numbers of nanoseconds.
+	DurationNanos DurationFormat = iota
+	// DurationString outputs durations as strings, as with
+	// time.Duration.String, like "1m30s".
+	DurationString
+	// DurationSeconds outputs durations as numbers of seconds, with
+	// fractions, like 90.5.
+	DurationSeconds
+)
+
+// safeSet returns the set of ASCII characters that a does not escape.
+func (a jsonAppender) safeSet() *[utf8.RuneSelf]bool {
+	if a.noHTMLEscape {
+		return &jsonSafeSet
+	}
+	return &htmlSafeSet
+}
 
 func (jsonAppender) appendStart(buf *buffer.Buffer) { buf.WriteByte('{') }
 func (jsonAppender) appendEnd(buf *buffer.Buffer)   { buf.WriteByte('}') }
 
+func (jsonAppender) nestsGroups() bool { return true }
+
+func (a jsonAppender) appendOpenGroup(buf *buffer.Buffer, key string) {
+	a.appendKey(buf, key)
+	buf.WriteByte('{')
+}
+
+func (jsonAppender) appendCloseGroup(buf *buffer.Buffer) { buf.WriteByte('}') }
+
 func (a jsonAppender) appendKey(buf *buffer.Buffer, key string) {
 	a.appendString(buf, key)
 	buf.WriteByte(':')
 }
 
-func (jsonAppender) appendString(buf *buffer.Buffer, s string) {
-	*buf = appendQuotedJSONString(*buf, s)
+func (a jsonAppender) appendString(buf *buffer.Buffer, s string) {
+	buf.WriteByte('"')
+	*buf = appendEscapedJSONString(*buf, s, a.safeSet())
+	buf.WriteByte('"')
 }
 
-func (jsonAppender) appendSource(buf *buffer.Buffer, file string, line int) {
+func (a jsonAppender) appendSource(buf *buffer.Buffer, file string, line int) {
 	buf.WriteByte('"')
-	*buf = appendJSONString(*buf, file)
+	*buf = appendEscapedJSONString(*buf, file, a.safeSet())
 	buf.WriteByte(':')
 	itoa((*[]byte)(buf), line, -1)
 	buf.WriteByte('"')
 }
 
-func (jsonAppender) appendTime(buf *buffer.Buffer, t time.Time) error {
-	b, err := t.MarshalJSON()
-	if err != nil {
-		return err
+func (a jsonAppender) appendTime(buf *buffer.Buffer, t time.Time) error {
+	if a.timeFormat != "" {
+		buf.WriteByte('"')
+		*buf = appendEscapedJSONString(*buf, t.Format(a.timeFormat), a.safeSet())
+		buf.WriteByte('"')
+		return nil
 	}
-	buf.Write(b)
+	// Do what time.Time.MarshalJSON does, without allocating.
+	if y := t.Year(); y < 0 || y >= 10000 {
+		return errors.New("Time.MarshalJSON: year outside of range [0,9999]")
+	}
+	buf.WriteByte('"')
+	*buf = t.AppendFormat(*buf, time.RFC3339Nano)
+	buf.WriteByte('"')
 	return nil
 }
 
@@ -116,34 +221,51 @@ func (app jsonAppender) appendAttrValue(buf *buffer.Buffer, a Attr) error {
 	case Uint64Kind:
 		*buf = strconv.AppendUint(*buf, a.Uint64(), 10)
 	case Float64Kind:
-		f := a.Float64()
-		// json.Marshal fails on special floats, so handle them here.
-		switch {
-		case math.IsInf(f, 1):
-			buf.WriteString(`"+Inf"`)
-		case math.IsInf(f, -1):
-			buf.WriteString(`"-Inf"`)
-		case math.IsNaN(f):
-			buf.WriteString(`"NaN"`)
-		default:
-			// json.Marshal is funny about floats; it doesn't
-			// always match strconv.AppendFloat. So just call it.
-			// That's expensive, but floats are rare.
-			if err := appendJSONMarshal(buf, f); err != nil {
-				return err
-			}
-		}
+		return app.appendFloat(buf, a.Float64(), a.floatBits())
 	case BoolKind:
 		*buf = strconv.AppendBool(*buf, a.Bool())
 	case DurationKind:
-		// Do what json.Marshal does.
-		*buf = strconv.AppendInt(*buf, int64(a.Duration()), 10)
+		switch d := a.Duration(); app.durations {
+		case DurationString:
+			buf.WriteByte('"')
+			buf.WriteString(d.String())
+			buf.WriteByte('"')
+		case DurationSeconds:
+			*buf = strconv.AppendFloat(*buf, d.Seconds(), 'f', -1, 64)
+		default:
+			// Do what json.Marshal does.
+			*buf = strconv.AppendInt(*buf, int64(d), 10)
+		}
 	case TimeKind:
 		if err := app.appendTime(buf, a.Time()); err != nil {
 			return err
 		}
 	case AnyKind:
-		if err := appendJSONMarshal(buf, a.Value()); err != nil {
+		if isNil(a.any) {
+			buf.WriteString("null")
+			return nil
+		}
+		if u, ok := uuidValue(a.any); ok {
+			buf.WriteByte('"')
+			*buf = appendUUID(*buf, u)
+			buf.WriteByte('"')
+			return nil
+		}
+		if d, ok := decimalValue(a.any); ok {
+			buf.WriteString(d)
+			return nil
+		}
+		if err, ok := a.any.(error); ok && !marshalsItself(err) {
+			return app.appendError(buf, err)
+		}
+		// json.Marshal fails on complex numbers.
+		switch c := a.any.(type) {
+		case complex128:
+			return app.appendComplex(buf, real(c), imag(c), 64)
+		case complex64:
+			return app.appendComplex(buf, float64(real(c)), float64(imag(c)), 32)
+		}
+		if err := app.appendJSONMarshal(buf, a.Value()); err != nil {
 			return err
 		}
 	default:
@@ -152,7 +274,70 @@ func (app jsonAppender) appendAttrValue(buf *buffer.Buffer, a Attr) error {
 	return nil
 }
 
-func appendJSONMarshal(buf *buffer.Buffer, v any) error {
+// appendFloat appends f, with the precision of a float of the given size
+// in bits.
+func (app jsonAppender) appendFloat(buf *buffer.Buffer, f float64, bits int) error {
+	// json.Marshal fails on special floats, so handle them here.
+	switch {
+	case math.IsInf(f, 1):
+		buf.WriteString(`"+Inf"`)
+	case math.IsInf(f, -1):
+		buf.WriteString(`"-Inf"`)
+	case math.IsNaN(f):
+		buf.WriteString(`"NaN"`)
+	default:
+		*buf = appendJSONFloat(*buf, f, bits)
+	}
+	return nil
+}
+
+// appendJSONFloat appends f, a float of the given size in bits, as
+// json.Marshal does. It doesn't always match strconv.AppendFloat.
+func appendJSONFloat(buf []byte, f float64, bits int) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9, as json.Marshal does.
+		if n := len(buf); n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+// appendComplex appends a complex number with the given parts as an object
+// with members "real" and "imag", each a float of the given size in bits.
+func (app jsonAppender) appendComplex(buf *buffer.Buffer, re, im float64, bits int) error {
+	buf.WriteString(`{"real":`)
+	if err := app.appendFloat(buf, re, bits); err != nil {
+		return err
+	}
+	buf.WriteString(`,"imag":`)
+	if err := app.appendFloat(buf, im, bits); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (app jsonAppender) appendJSONMarshal(buf *buffer.Buffer, v any) error {
+	if app.noHTMLEscape {
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		// Remove the newline added by Encode.
+		*buf = (*buf)[:len(*buf)-1]
+		return nil
+	}
 	b, err := json.Marshal(v)
 	if err != nil {
 		return err
@@ -167,21 +352,27 @@ func appendQuotedJSONString(buf []byte, s string) []byte {
 	return append(buf, '"')
 }
 
-// appendJSONString escapes s for JSON and appends it to buf.
+// appendJSONString escapes s for JSON, including the characters special to
+// HTML, and appends it to buf.
 // It does not surround the string in quotation marks.
-//
-// Modified from encoding/json/encode.go:encodeState.string,
-// with escapeHTML set to true.
-//
-// TODO: review whether HTML escaping is necessary.
 func appendJSONString(buf []byte, s string) []byte {
+	return appendEscapedJSONString(buf, s, &htmlSafeSet)
+}
+
+// appendEscapedJSONString escapes s for JSON and appends it to buf. The ASCII
+// characters in safeSet are not escaped; it should be htmlSafeSet or
+// jsonSafeSet.
+// It does not surround the string in quotation marks.
+//
+// Modified from encoding/json/encode.go:encodeState.string.
+func appendEscapedJSONString(buf []byte, s string, safeSet *[utf8.RuneSelf]bool) []byte {
 	char := func(b byte) { buf = append(buf, b) }
 	str := func(s string) { buf = append(buf, s...) }
 
 	start := 0
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
-			if htmlSafeSet[b] {
+			if safeSet[b] {
 				i++
 				continue
 			}
@@ -200,7 +391,7 @@ func appendJSONString(buf []byte, s string) []byte {
 				char('t')
 			default:
 				// This encodes bytes < 0x20 except for \t, \n and \r.
-				// It also escapes <, >, and &
+				// With htmlSafeSet, it also escapes <, >, and &
 				// because they can lead to security holes when
 				// user-controlled strings are rendered into JSON
 				// and served to some browsers.
@@ -356,3 +547,13 @@ var htmlSafeSet = [utf8.RuneSelf]bool{
 	'~':      true,
 	'\u007f': true,
 }
+
+// jsonSafeSet is like htmlSafeSet, but also holds true for the characters
+// special to HTML. It is used when [HandlerOptions.NoHTMLEscape] is set.
+var jsonSafeSet = htmlSafeSet
+
+func init() {
+	jsonSafeSet['<'] = true
+	jsonSafeSet['>'] = true
+	jsonSafeSet['&'] = true
+}
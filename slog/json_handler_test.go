@@ -12,6 +12,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -109,6 +110,61 @@ func TestJSONAppendAttrValue(t *testing.T) {
 	}
 }
 
+func TestJSONAppendAttrValueNoHTMLEscape(t *testing.T) {
+	// With noHTMLEscape, appendAttrValue should agree with an Encoder
+	// that does not escape HTML.
+	for _, value := range []any{
+		"<escapeHTML&>",
+		"line\u2028para\u2029",
+		`"[{escape}]"`,
+		map[string]string{"<a>": "b&c"},
+		jsonMarshaler{"<&>"},
+	} {
+		var buf []byte
+		app := jsonAppender{noHTMLEscape: true}
+		if err := app.appendAttrValue((*buffer.Buffer)(&buf), Any("", value)); err != nil {
+			t.Fatal(err)
+		}
+		got := string(buf)
+		var want bytes.Buffer
+		enc := json.NewEncoder(&want)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(value); err != nil {
+			t.Fatal(err)
+		}
+		if got != strings.TrimSuffix(want.String(), "\n") {
+			t.Errorf("%v: got %s, want %s", value, got, want.String())
+		}
+	}
+}
+
+func TestJSONHandlerNoHTMLEscape(t *testing.T) {
+	for _, test := range []struct {
+		opts HandlerOptions
+		want string
+	}{
+		{
+			HandlerOptions{},
+			`{"level":"INFO","msg":"\u003cm\u003e","\u0026k":"\u003cescapeHTML\u0026\u003e","s":"\u2028"}`,
+		},
+		{
+			HandlerOptions{NoHTMLEscape: true},
+			`{"level":"INFO","msg":"<m>","&k":"<escapeHTML&>","s":"\u2028"}`,
+		},
+	} {
+		var buf bytes.Buffer
+		h := test.opts.NewJSONHandler(&buf)
+		r := NewRecord(time.Time{}, InfoLevel, "<m>", 0)
+		r.AddAttrs(String("&k", "<escapeHTML&>"), String("s", "\u2028"))
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+			t.Errorf("NoHTMLEscape=%t:\ngot  %s\nwant %s", test.opts.NoHTMLEscape, got, test.want)
+		}
+	}
+}
+
 func TestJSONAppendAttrValueSpecial(t *testing.T) {
 	// Attr values that render differently from json.Marshal.
 	for _, test := range []struct {
@@ -133,6 +189,87 @@ func TestJSONAppendAttrValueSpecial(t *testing.T) {
 	}
 }
 
+func BenchmarkReplaceAttr(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		rep  func([]string, Attr) Attr
+	}{
+		{"nil", nil},
+		{"identity", func(_ []string, a Attr) Attr { return a }},
+	} {
+		for _, handler := range []struct {
+			name string
+			new  func(HandlerOptions, io.Writer) Handler
+		}{
+			{"json", func(o HandlerOptions, w io.Writer) Handler { return o.NewJSONHandler(w) }},
+			{"text", func(o HandlerOptions, w io.Writer) Handler { return o.NewTextHandler(w) }},
+		} {
+			b.Run(bench.name+"/"+handler.name, func(b *testing.B) {
+				h := handler.new(HandlerOptions{ReplaceAttr: bench.rep}, io.Discard).
+					With([]Attr{String("program", "my-test-program")})
+				r := NewRecord(time.Now(), InfoLevel, "this is a typical log message", 0)
+				r.AddAttrs(
+					String("module", "github.com/google/go-cmp"),
+					Int("count", 1000),
+					Float64("ratio", 0.25),
+					Duration("elapsed", time.Second),
+					Bool("ok", true))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := h.Handle(r); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// bigStruct is a LogValuer whose JSON output is about 20KB.
+type bigStruct struct{ attr Attr }
+
+func newBigStruct() bigStruct {
+	as := make([]Attr, 256)
+	for i := range as {
+		as[i] = String(fmt.Sprintf("field%03d", i), strings.Repeat("x", 64))
+	}
+	return bigStruct{Group("", as...)}
+}
+
+func (s bigStruct) LogValue() Attr { return s.attr }
+
+func BenchmarkBufferSize(b *testing.B) {
+	s := newBigStruct()
+	for _, size := range []int{0, 32 << 10} {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			h := HandlerOptions{BufferSize: size}.NewJSONHandler(io.Discard)
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(Any("big", s))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h.Handle(r)
+			}
+		})
+	}
+}
+
+func TestJSONHandlerBufferSize(t *testing.T) {
+	// BufferSize does not change the output.
+	s := newBigStruct()
+	var buf0, buf1 bytes.Buffer
+	for i := 0; i < 2; i++ {
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(Any("big", s))
+		NewJSONHandler(&buf0).Handle(r)
+		HandlerOptions{BufferSize: 32 << 10}.NewJSONHandler(&buf1).With([]Attr{}).Handle(r)
+	}
+	if buf1.Len() < 2*(16<<10) || buf0.String() != buf1.String() {
+		t.Errorf("got %d bytes with BufferSize, %d without; want equal output over 32KB", buf1.Len(), buf0.Len())
+	}
+}
+
 func BenchmarkJSONHandler(b *testing.B) {
 	for _, bench := range []struct {
 		name string
@@ -140,7 +277,7 @@ func BenchmarkJSONHandler(b *testing.B) {
 	}{
 		{"defaults", HandlerOptions{}},
 		{"time format", HandlerOptions{
-			ReplaceAttr: func(a Attr) Attr {
+			ReplaceAttr: func(groups []string, a Attr) Attr {
 				if a.Kind() == TimeKind {
 					return String(a.Key(), a.Time().Format(rfc3339Millis))
 				}
@@ -151,7 +288,7 @@ func BenchmarkJSONHandler(b *testing.B) {
 			},
 		}},
 		{"time unix", HandlerOptions{
-			ReplaceAttr: func(a Attr) Attr {
+			ReplaceAttr: func(groups []string, a Attr) Attr {
 				if a.Kind() == TimeKind {
 					return Int64(a.Key(), a.Time().UnixNano())
 				}
@@ -269,3 +406,297 @@ func BenchmarkJSONEncoding(b *testing.B) {
 	})
 	_ = buf
 }
+
+func BenchmarkJSONAppendString(b *testing.B) {
+	s := strings.Repeat("GET /api/v1/users?id=12345&name=gopher <ok> ", 4)
+	for _, noHTMLEscape := range []bool{false, true} {
+		b.Run(fmt.Sprintf("noHTMLEscape=%t", noHTMLEscape), func(b *testing.B) {
+			app := jsonAppender{noHTMLEscape: noHTMLEscape}
+			buf := buffer.New()
+			defer buf.Free()
+			b.ReportAllocs()
+			b.SetBytes(int64(len(s)))
+			for i := 0; i < b.N; i++ {
+				app.appendString(buf, s)
+				*buf = (*buf)[:0]
+			}
+		})
+	}
+}
+
+func TestJSONAppendAttrValueNil(t *testing.T) {
+	for _, value := range []any{
+		nil,
+		(*int)(nil),
+		(*jsonMarshaler)(nil),
+		map[string]int(nil),
+		[]int(nil),
+		error(nil),
+	} {
+		var buf []byte
+		if err := (jsonAppender{}).appendAttrValue((*buffer.Buffer)(&buf), Any("", value)); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(buf), "null"; got != want {
+			t.Errorf("%T: got %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestJSONHandlerTimeFormat(t *testing.T) {
+	tm := time.Date(2000, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	for _, test := range []struct {
+		name string
+		opts HandlerOptions
+		want string
+	}{
+		{
+			"default",
+			HandlerOptions{},
+			`{"time":"2000-01-02T03:04:05.006Z","level":"INFO","msg":"m","t":"2000-01-02T03:04:05.006Z"}`,
+		},
+		{
+			"layout",
+			HandlerOptions{TimeFormat: time.Kitchen},
+			`{"time":"3:04AM","level":"INFO","msg":"m","t":"3:04AM"}`,
+		},
+		{
+			"ReplaceAttr",
+			HandlerOptions{
+				TimeFormat: "2006-01-02",
+				ReplaceAttr: func(groups []string, a Attr) Attr {
+					if a.Key() == "time" {
+						return Int64(a.Key(), a.Time().Unix())
+					}
+					return a
+				},
+			},
+			`{"time":946782245,"level":"INFO","msg":"m","t":"2000-01-02"}`,
+		},
+		{
+			"TimeResolution",
+			HandlerOptions{TimeFormat: time.Kitchen, TimeResolution: time.Second},
+			`{"time":946782245,"level":"INFO","msg":"m","t":"3:04AM"}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := NewRecord(tm, InfoLevel, "m", 0)
+			r.AddAttrs(Time("t", tm))
+			if err := test.opts.NewJSONHandler(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestJSONHandlerDurationFormat(t *testing.T) {
+	d := 90*time.Second + 500*time.Millisecond
+	for _, test := range []struct {
+		opts HandlerOptions
+		want string
+	}{
+		{HandlerOptions{}, `"d":90500000000`},
+		{HandlerOptions{DurationFormat: DurationNanos}, `"d":90500000000`},
+		{HandlerOptions{DurationFormat: DurationString}, `"d":"1m30.5s"`},
+		{HandlerOptions{DurationFormat: DurationSeconds}, `"d":90.5`},
+		{
+			HandlerOptions{
+				DurationFormat: DurationString,
+				ReplaceAttr: func(groups []string, a Attr) Attr {
+					if a.Kind() == DurationKind {
+						return Int64(a.Key(), a.Duration().Milliseconds())
+					}
+					return a
+				},
+			},
+			`"d":90500`,
+		},
+	} {
+		var buf bytes.Buffer
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(Duration("d", d))
+		if err := test.opts.NewJSONHandler(&buf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		want := `{"level":"INFO","msg":"m",` + test.want + "}\n"
+		if got := buf.String(); got != want {
+			t.Errorf("DurationFormat %d: got %s, want %s", test.opts.DurationFormat, got, want)
+		}
+	}
+}
+
+func TestJSONHandlerGroup(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(&buf).With([]Attr{Group("w", Int("x", 0))})
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(Group("g", Int("a", 1), Group("h", String("b", "c")), Group("empty")), Int("d", 2))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"INFO","msg":"m","w":{"x":0},"g":{"a":1,"h":{"b":"c"}},"d":2}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONHandlerWithGroup(t *testing.T) {
+	// A group of the handler is a nested object holding the Attrs of both
+	// With and the record, and is omitted if it has none.
+	var buf bytes.Buffer
+	l := New(NewJSONHandler(&buf))
+	l.WithGroup("g").With("a", 1).LogAt(time.Time{}, InfoLevel, "m", "b", 2)
+	l.WithGroup("g").WithGroup("h").With("a", 1).LogAt(time.Time{}, InfoLevel, "m", "b", 2)
+	l.WithGroup("g").LogAt(time.Time{}, InfoLevel, "m")
+	l.WithGroup("g").LogAt(time.Time{}, InfoLevel, "m", Group("e", Group("f")))
+	want := `{"level":"INFO","msg":"m","g":{"a":1,"b":2}}
+{"level":"INFO","msg":"m","g":{"h":{"a":1,"b":2}}}
+{"level":"INFO","msg":"m"}
+{"level":"INFO","msg":"m"}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONHandlerIndent(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions{Indent: "  "}.NewJSONHandler(&buf)
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(Group("g", Int("a", 1), Group("h", String("b", "c"))), Any("v", []int{1, 2}))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "level": "INFO",
+  "msg": "m",
+  "g": {
+    "a": 1,
+    "h": {
+      "b": "c"
+    }
+  },
+  "v": [
+    1,
+    2
+  ]
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONHandlerSourceAsGroup(t *testing.T) {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	f, _ := runtime.CallersFrames(pcs[:]).Next()
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.SetPC(pcs[0])
+
+	for _, test := range []struct {
+		name string
+		opts HandlerOptions
+		want string
+	}{
+		{
+			"string",
+			HandlerOptions{AddSource: true},
+			fmt.Sprintf(`"source":"%s:%d"`, f.File, f.Line),
+		},
+		{
+			"group",
+			HandlerOptions{AddSource: true, SourceAsGroup: true},
+			fmt.Sprintf(`"source":{"function":%q,"file":%q,"line":%d}`, f.Function, f.File, f.Line),
+		},
+		{
+			"ReplaceAttr",
+			HandlerOptions{
+				AddSource:     true,
+				SourceAsGroup: true,
+				ReplaceAttr: func(_ []string, a Attr) Attr {
+					if a.Key() == "source" && a.Kind() == GroupKind {
+						return String("where", a.Group()[0].str())
+					}
+					return a
+				},
+			},
+			fmt.Sprintf(`"where":%q`, f.Function),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := test.opts.NewJSONHandler(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			want := `{"level":"INFO",` + test.want + `,"msg":"m"}` + "\n"
+			if got := buf.String(); got != want {
+				t.Errorf("\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+	if !strings.HasSuffix(f.Function, "TestJSONHandlerSourceAsGroup") {
+		t.Errorf("function %q does not name the test", f.Function)
+	}
+}
+
+func TestJSONAppendFloat32(t *testing.T) {
+	// The output matches json.Marshal.
+	for _, f := range []float32{0, 0.1, -2.5, 1e-6, 1e-7, 123456789, 1e20, 1e21, 3.4028235e38, -1.5e-9} {
+		want, err := json.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appendJSONFloat(nil, float64(f), 32); string(got) != string(want) {
+			t.Errorf("%v: got %s, want %s", f, got, want)
+		}
+	}
+}
+
+func TestJSONAppendFloat64(t *testing.T) {
+	// The output matches json.Marshal.
+	for _, f := range []float64{0, 0.1, -2.5, 1e-6, 1e-7, 123456789, 1e20, 1e21, math.MaxFloat64, -1.5e-9, 1.0 / 3} {
+		want, err := json.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appendJSONFloat(nil, f, 64); string(got) != string(want) {
+			t.Errorf("%v: got %s, want %s", f, got, want)
+		}
+	}
+}
+
+func TestJSONHandlerComplex(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(
+		Any("c", complex(1.5, -2)),
+		Any("c64", complex64(complex(0.1, 0))),
+		Any("inf", complex(math.Inf(1), math.NaN())),
+	)
+	if err := NewJSONHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"level":"INFO","msg":"m","c":{"real":1.5,"imag":-2},"c64":{"real":0.1,"imag":0},` +
+		`"inf":{"real":"+Inf","imag":"NaN"}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONHandlerWithWriter(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	h := NewJSONHandler(&buf1).With([]Attr{Int("a", 1)})
+	if err := h.(*JSONHandler).WithWriter(&buf2).Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf2.String(), `{"level":"INFO","msg":"m","a":1}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if buf1.Len() != 0 {
+		t.Errorf("original writer got %q", buf1.String())
+	}
+}
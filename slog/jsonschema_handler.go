@@ -0,0 +1,244 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// A SchemaValidator validates a JSON document, decoded as with
+// encoding/json.Unmarshal into an any. Implementations can adapt a
+// full-featured JSON Schema library.
+type SchemaValidator interface {
+	Validate(doc any) error
+}
+
+// SchemaValidatingHandler is a Handler that checks each Record against a
+// JSON Schema before passing it to another Handler. It is intended for tests.
+type SchemaValidatingHandler struct {
+	inner     Handler
+	validator SchemaValidator
+
+	mu   *sync.Mutex // guards buf
+	buf  *bytes.Buffer
+	json Handler // formats records to buf
+}
+
+// NewSchemaValidatingHandler creates a SchemaValidatingHandler that validates
+// records against schema, a JSON Schema, and passes valid records to inner.
+//
+// Only a subset of JSON Schema is supported: the keywords "type",
+// "properties", "required", "additionalProperties" (as a boolean), "items"
+// and "enum". Other keywords are ignored. For full support, use
+// [NewSchemaValidatingHandlerWith].
+func NewSchemaValidatingHandler(inner Handler, schema []byte) (*SchemaValidatingHandler, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("slog: invalid JSON Schema: %w", err)
+	}
+	return NewSchemaValidatingHandlerWith(inner, &s), nil
+}
+
+// NewSchemaValidatingHandlerWith creates a SchemaValidatingHandler that
+// validates records with v, and passes valid records to inner.
+//
+// Records are formatted for validation by a JSONHandler with the default
+// options.
+func NewSchemaValidatingHandlerWith(inner Handler, v SchemaValidator) *SchemaValidatingHandler {
+	buf := &bytes.Buffer{}
+	return &SchemaValidatingHandler{
+		inner:     inner,
+		validator: v,
+		mu:        &sync.Mutex{},
+		buf:       buf,
+		json:      NewJSONHandler(buf),
+	}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *SchemaValidatingHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new SchemaValidatingHandler whose inner handler,
+// and the records it validates, have the given attributes.
+func (h *SchemaValidatingHandler) With(attrs []Attr) Handler {
+	h2 := *h
+	h2.inner = h.inner.With(attrs)
+	h2.json = h.json.With(concat(attrs, nil))
+	return &h2
+}
+
+// WithGroup returns a new SchemaValidatingHandler whose inner handler,
+// and the records it validates, have the given group.
+func (h *SchemaValidatingHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.inner = h.inner.WithGroup(name)
+	h2.json = h.json.WithGroup(name)
+	return &h2
+}
+
+// Handle validates r. If it is valid, Handle passes it to the inner handler.
+// Otherwise, it returns an error describing the problem.
+func (h *SchemaValidatingHandler) Handle(r Record) error {
+	h.mu.Lock()
+	h.buf.Reset()
+	err := h.json.Handle(r)
+	var doc any
+	if err == nil {
+		err = json.Unmarshal(h.buf.Bytes(), &doc)
+	}
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := h.validator.Validate(doc); err != nil {
+		return fmt.Errorf("slog: record does not match schema: %w", err)
+	}
+	return h.inner.Handle(r)
+}
+
+// jsonSchema is the supported subset of JSON Schema.
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+}
+
+// schemaTypes is the value of the "type" keyword,
+// which is either a string or an array of strings.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = schemaTypes{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// Validate implements SchemaValidator.
+func (s *jsonSchema) Validate(doc any) error {
+	return s.validate("", doc)
+}
+
+func (s *jsonSchema) validate(path string, v any) error {
+	if path == "" {
+		path = "/"
+	}
+	if len(s.Type) > 0 {
+		ok := false
+		for _, t := range s.Type {
+			if hasSchemaType(v, t) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%s: got %s, want type %v", path, schemaTypeOf(v), []string(s.Type))
+		}
+	}
+	if len(s.Enum) > 0 {
+		ok := false
+		for _, e := range s.Enum {
+			if jsonEqual(e, v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%s: %v is not one of %v", path, v, s.Enum)
+		}
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for _, k := range s.Required {
+			if _, ok := v[k]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, k)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+				continue
+			}
+			if err := p.validate(joinPointer(path, k), v[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, e := range v {
+				if err := s.Items.validate(joinPointer(path, fmt.Sprint(i)), e); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func joinPointer(path, elem string) string {
+	if path == "/" {
+		return path + elem
+	}
+	return path + "/" + elem
+}
+
+func hasSchemaType(v any, t string) bool {
+	if t == "integer" {
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return schemaTypeOf(v) == t
+}
+
+// schemaTypeOf returns the JSON Schema type of a value decoded by
+// encoding/json. Numbers are reported as "number".
+func schemaTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// jsonEqual reports whether two values decoded by encoding/json are equal.
+func jsonEqual(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(ab, bb)
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["level", "msg", "user"],
+	"properties": {
+		"level": {"enum": ["INFO", "WARN", "ERROR"]},
+		"msg": {"type": "string"},
+		"user": {"type": "integer"},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	},
+	"additionalProperties": false
+}`
+
+func TestSchemaValidatingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewSchemaValidatingHandler(NewTextHandler(&buf), []byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		with    []Attr
+		attrs   []Attr
+		wantErr string
+	}{
+		{"valid", nil, []Attr{Int("user", 1), Any("tags", []string{"a"})}, ""},
+		{"valid with", []Attr{Int("user", 1)}, nil, ""},
+		{"missing", nil, nil, `missing required property "user"`},
+		{"wrong type", nil, []Attr{String("user", "u")}, "/user: got string, want type [integer]"},
+		{"not integer", nil, []Attr{Float64("user", 1.5)}, "/user: got number, want type [integer]"},
+		{"items", nil, []Attr{Int("user", 1), Any("tags", []int{1})}, "/tags/0: got number"},
+		{"additional", nil, []Attr{Int("user", 1), Int("extra", 2)}, `unexpected property "extra"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(test.attrs...)
+			err := h.With(test.with).Handle(r)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if buf.Len() == 0 {
+					t.Error("valid record was not passed on")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, test.wantErr)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("invalid record was passed on: %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestSchemaValidatingHandlerBadSchema(t *testing.T) {
+	if _, err := NewSchemaValidatingHandler(NewTextHandler(&bytes.Buffer{}), []byte("{")); err == nil {
+		t.Error("got nil error for invalid schema")
+	}
+}
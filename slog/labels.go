@@ -0,0 +1,91 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LabelsKey is the key used by Labels.
+const LabelsKey = "labels"
+
+// Labels returns a group Attr with key "labels" holding one String Attr for
+// each label, sorted by name, as Kubernetes and Prometheus present labels.
+// If labels is empty, Labels returns the zero Attr, which handlers do not
+// output.
+//
+// When the program is built with the slog_strict build tag, each label whose
+// name is not a valid Kubernetes label name is followed in the group by an
+// Attr with key "!SCHEMA" describing the problem, as with RegisterSchema.
+// A valid name is an optional DNS subdomain prefix and a slash, followed by
+// at most 63 letters, digits, '-', '_' and '.', beginning and ending with a
+// letter or digit.
+func Labels(labels map[string]string) Attr {
+	if len(labels) == 0 {
+		return Attr{}
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	as := make([]Attr, 0, len(names))
+	for _, name := range names {
+		as = append(as, String(name, labels[name]))
+		if checkSchemas && !validLabelName(name) {
+			as = append(as, String(schemaKey, fmt.Sprintf("invalid label name %q", name)))
+		}
+	}
+	return Group(LabelsKey, as...)
+}
+
+// validLabelName reports whether name is a valid Kubernetes label name.
+func validLabelName(name string) bool {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		if !validDNSSubdomain(name[:i]) {
+			return false
+		}
+		name = name[i+1:]
+	}
+	if len(name) == 0 || len(name) > 63 || !isAlnum(name[0]) || !isAlnum(name[len(name)-1]) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !isAlnum(c) && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// validDNSSubdomain reports whether s is a valid DNS subdomain, as in
+// RFC 1123: at most 253 characters of dot-separated labels of lower-case
+// letters, digits and '-', beginning and ending with a letter or digit.
+func validDNSSubdomain(s string) bool {
+	if len(s) == 0 || len(s) > 253 {
+		return false
+	}
+	for _, l := range strings.Split(s, ".") {
+		if len(l) == 0 || !isLowerAlnum(l[0]) || !isLowerAlnum(l[len(l)-1]) {
+			return false
+		}
+		for i := 0; i < len(l); i++ {
+			if c := l[i]; !isLowerAlnum(c) && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isLowerAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
+
+func isAlnum(c byte) bool {
+	return isLowerAlnum(c) || 'A' <= c && c <= 'Z'
+}
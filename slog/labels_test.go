@@ -0,0 +1,95 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLabels(t *testing.T) {
+	defer func(b bool) { checkSchemas = b }(checkSchemas)
+	labels := map[string]string{"tier": "web", "app": "shop", "app.kubernetes.io/version": "1.2"}
+	for _, test := range []struct {
+		strict   bool
+		labels   map[string]string
+		wantText string
+		wantJSON string
+	}{
+		{
+			false, labels,
+			`labels.app=shop labels.app.kubernetes.io/version=1.2 labels.tier=web`,
+			`"labels":{"app":"shop","app.kubernetes.io/version":"1.2","tier":"web"}`,
+		},
+		{
+			true, labels,
+			`labels.app=shop labels.app.kubernetes.io/version=1.2 labels.tier=web`,
+			`"labels":{"app":"shop","app.kubernetes.io/version":"1.2","tier":"web"}`,
+		},
+		{
+			false, map[string]string{"bad name": "x"},
+			`"labels.bad name"=x`,
+			`"labels":{"bad name":"x"}`,
+		},
+		{
+			true, map[string]string{"bad name": "x", "ok": "y"},
+			`"labels.bad name"=x labels.!SCHEMA="invalid label name \"bad name\"" labels.ok=y`,
+			`"labels":{"bad name":"x","!SCHEMA":"invalid label name \"bad name\"","ok":"y"}`,
+		},
+		{true, nil, ``, ``},
+	} {
+		checkSchemas = test.strict
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(Labels(test.labels))
+		var tbuf, jbuf bytes.Buffer
+		if err := NewTextHandler(&tbuf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewJSONHandler(&jbuf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		wantText, wantJSON := "level=INFO msg=m", `{"level":"INFO","msg":"m"`
+		if test.wantText != "" {
+			wantText += " " + test.wantText
+			wantJSON += "," + test.wantJSON
+		}
+		wantJSON += "}"
+		if got := strings.TrimSuffix(tbuf.String(), "\n"); got != wantText {
+			t.Errorf("strict=%t, text:\ngot  %s\nwant %s", test.strict, got, wantText)
+		}
+		if got := strings.TrimSuffix(jbuf.String(), "\n"); got != wantJSON {
+			t.Errorf("strict=%t, JSON:\ngot  %s\nwant %s", test.strict, got, wantJSON)
+		}
+	}
+}
+
+func TestValidLabelName(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		want bool
+	}{
+		{"app", true},
+		{"App_1.x-y", true},
+		{"example.com/app", true},
+		{"a", true},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+		{"", false},
+		{"-app", false},
+		{"app-", false},
+		{"bad name", false},
+		{"Example.com/app", false},
+		{"example..com/app", false},
+		{"/app", false},
+		{"example.com/", false},
+		{"a/b/c", false},
+	} {
+		if got := validLabelName(test.name); got != test.want {
+			t.Errorf("%q: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}
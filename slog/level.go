@@ -5,8 +5,10 @@
 package slog
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync/atomic"
 )
 
@@ -76,6 +78,18 @@ func (l Level) String() string {
 	}
 }
 
+// shortString is like String, but abbreviates the name of the
+// level to its first letter.
+func (l Level) shortString() string {
+	s := l.String()
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		return s[:1] + s[i:]
+	}
+	return s[:1]
+}
+
+// MarshalJSON implements encoding/json.Marshaler
+// by quoting the output of String.
 func (l Level) MarshalJSON() ([]byte, error) {
 	// AppendQuote is sufficient for JSON-encoding all Level strings.
 	// They don't contain any runes that would produce invalid JSON
@@ -83,37 +97,114 @@ func (l Level) MarshalJSON() ([]byte, error) {
 	return strconv.AppendQuote(nil, l.String()), nil
 }
 
+// UnmarshalJSON implements encoding/json.Unmarshaler.
+// It accepts a string, as described at ParseLevel, or an integer.
+// As is the convention, null leaves l unchanged.
+func (l *Level) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("slog: level %s: %v", data, err)
+		}
+		return l.UnmarshalText([]byte(s))
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		return fmt.Errorf("slog: level %s is neither a string nor an integer", data)
+	}
+	*l = Level(n)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+// by returning the output of String.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// It accepts any string produced by String, as described at ParseLevel.
+func (l *Level) UnmarshalText(data []byte) error {
+	v, err := ParseLevel(string(data))
+	if err != nil {
+		return err
+	}
+	*l = v
+	return nil
+}
+
+// ParseLevel returns the level named by s, as produced by Level.String:
+// one of the names DEBUG, INFO, WARN and ERROR, optionally followed by a
+// signed offset, as in "WARN-1" or "INFO+2". Case is ignored.
+// ParseLevel returns an error if the name is unknown or the offset is not
+// an integer.
+func ParseLevel(s string) (Level, error) {
+	name, offset := s, 0
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		name = s[:i]
+		var err error
+		offset, err = strconv.Atoi(s[i:])
+		if err != nil {
+			return 0, fmt.Errorf("slog: level %q: bad offset %q", s, s[i:])
+		}
+	}
+	var l Level
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		l = DebugLevel
+	case "INFO":
+		l = InfoLevel
+	case "WARN":
+		l = WarnLevel
+	case "ERROR":
+		l = ErrorLevel
+	default:
+		return 0, fmt.Errorf("slog: level %q: unknown name %q", s, name)
+	}
+	return l + Level(offset), nil
+}
+
 // Level returns the receiver.
 // It implements Leveler.
 func (l Level) Level() Level { return l }
 
-// An AtomicLevel is a Level that can be read and written safely by multiple
-// goroutines.
-// The default value of AtomicLevel is InfoLevel.
-type AtomicLevel struct {
+// A LevelVar is a Level variable, to allow a Handler level to change
+// dynamically.
+// It implements Leveler as well as a Set method,
+// and it is safe for use by multiple goroutines.
+// The zero LevelVar corresponds to InfoLevel.
+type LevelVar struct {
 	val atomic.Int64
 }
 
-// Level returns r's level.
-func (a *AtomicLevel) Level() Level {
-	return Level(int(a.val.Load()))
+// Level returns v's level.
+func (v *LevelVar) Level() Level {
+	return Level(int(v.val.Load()))
 }
 
-// Set sets the receiver's level to l.
-func (a *AtomicLevel) Set(l Level) {
-	a.val.Store(int64(l))
+// Set sets v's level to l.
+func (v *LevelVar) Set(l Level) {
+	v.val.Store(int64(l))
 }
 
-func (a *AtomicLevel) String() string {
-	return fmt.Sprintf("AtomicLevel(%s)", a.Level())
+func (v *LevelVar) String() string {
+	return fmt.Sprintf("LevelVar(%s)", v.Level())
 }
 
+// An AtomicLevel is a LevelVar.
+//
+// Deprecated: Use LevelVar.
+type AtomicLevel = LevelVar
+
 // A Leveler provides a Level value.
 //
 // As Level itself implements Leveler, clients typically supply
 // a Level value wherever a Leveler is needed, such as in HandlerOptions.
 // Clients who need to vary the level dynamically can provide a more complex
-// Leveler implementation such as *AtomicLevel.
+// Leveler implementation such as *LevelVar.
 type Leveler interface {
 	Level() Level
 }
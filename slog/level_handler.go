@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+// NewLevelHandler returns a Handler that passes records to h, but only
+// those whose level is at least level.Level(), regardless of the levels at
+// which h is enabled. Its Enabled method compares with level alone, so it
+// can also enable levels that h would not. Combined with MultiHandler, it
+// sends records of different verbosities to different handlers.
+func NewLevelHandler(level Leveler, h Handler) Handler {
+	// Optimization: avoid chains of levelHandlers.
+	if lh, ok := h.(*levelHandler); ok {
+		h = lh.inner
+	}
+	return &levelHandler{level: level, inner: h}
+}
+
+type levelHandler struct {
+	level Leveler
+	inner Handler
+}
+
+func (h *levelHandler) Enabled(l Level) bool {
+	return l >= h.level.Level()
+}
+
+// Handle passes r to the inner handler if h is enabled at r's level.
+func (h *levelHandler) Handle(r Record) error {
+	if !h.Enabled(r.Level()) {
+		return nil
+	}
+	return h.inner.Handle(r)
+}
+
+func (h *levelHandler) With(attrs []Attr) Handler {
+	return &levelHandler{level: h.level, inner: h.inner.With(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &levelHandler{level: h.level, inner: h.inner.WithGroup(name)}
+}
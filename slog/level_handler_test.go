@@ -0,0 +1,53 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var level AtomicLevel
+	// The inner handler accepts everything from DebugLevel up.
+	inner := HandlerOptions{Level: DebugLevel}.NewTextHandler(&buf)
+	h := NewLevelHandler(&level, inner)
+	l := New(h).With("a", 1)
+	for _, test := range []struct {
+		level Level
+		want  string
+	}{
+		{InfoLevel, "level=INFO msg=info a=1~level=WARN msg=warn a=1"},
+		{WarnLevel, "level=WARN msg=warn a=1"},
+		{ErrorLevel, ""},
+		{DebugLevel, "level=DEBUG msg=debug a=1~level=INFO msg=info a=1~level=WARN msg=warn a=1"},
+	} {
+		level.Set(test.level)
+		buf.Reset()
+		for _, lv := range []Level{DebugLevel, InfoLevel, WarnLevel} {
+			l.LogAt(time.Time{}, lv, strings.ToLower(lv.String()))
+		}
+		if got := clean(buf.String()); got != test.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", test.level, got, test.want)
+		}
+	}
+
+	// Handle does not pass disabled records to the inner handler,
+	// even when called directly.
+	level.Set(ErrorLevel)
+	buf.Reset()
+	if err := h.WithGroup("g").Handle(NewRecord(time.Time{}, WarnLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got %q, want no output", buf.String())
+	}
+	if !h.Enabled(ErrorLevel) || h.Enabled(WarnLevel) {
+		t.Error("Enabled does not compare with the LevelHandler's level")
+	}
+}
@@ -5,7 +5,11 @@
 package slog
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLevelString(t *testing.T) {
@@ -46,3 +50,154 @@ func TestAtomicLevel(t *testing.T) {
 	}
 
 }
+
+func TestLevelVar(t *testing.T) {
+	var v LevelVar
+	if got, want := v.String(), "LevelVar(INFO)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var buf bytes.Buffer
+	l := New(HandlerOptions{Level: &v}.NewTextHandler(&buf))
+	l.LogAt(time.Time{}, DebugLevel, "1")
+	l.LogAt(time.Time{}, InfoLevel, "2")
+	v.Set(DebugLevel)
+	l.LogAt(time.Time{}, DebugLevel, "3")
+	v.Set(WarnLevel)
+	l.LogAt(time.Time{}, InfoLevel, "4")
+	l.LogAt(time.Time{}, WarnLevel, "5")
+	want := "level=INFO msg=2\nlevel=DEBUG msg=3\nlevel=WARN msg=5"
+	if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+	if got, want := v.String(), "LevelVar(WARN)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLevelShortString(t *testing.T) {
+	for _, test := range []struct {
+		in   Level
+		want string
+	}{
+		{DebugLevel, "D"},
+		{InfoLevel, "I"},
+		{WarnLevel, "W"},
+		{ErrorLevel, "E"},
+		{ErrorLevel + 2, "E+2"},
+		{DebugLevel - 2, "D-2"},
+		{WarnLevel - 1, "I+3"},
+	} {
+		if got := test.in.shortString(); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	// Round trip through String.
+	for l := DebugLevel - 6; l <= ErrorLevel+6; l++ {
+		got, err := ParseLevel(l.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != l {
+			t.Errorf("%s: got %d, want %d", l, got, l)
+		}
+	}
+
+	for _, test := range []struct {
+		in   string
+		want Level
+	}{
+		{"warn", WarnLevel},
+		{"Error", ErrorLevel},
+		{"debug-2", DebugLevel - 2},
+		{"INFO+2", InfoLevel + 2},
+		{"WARN+6", ErrorLevel + 2},
+	} {
+		got, err := ParseLevel(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%q: got %s, want %s", test.in, got, test.want)
+		}
+	}
+
+	for _, in := range []string{"", "NOTICE", "INFO+", "INFO+x", "+1", "WARN 1"} {
+		if _, err := ParseLevel(in); err == nil {
+			t.Errorf("%q: got nil error", in)
+		}
+	}
+	if _, err := ParseLevel("FATAL+1"); err == nil || !strings.Contains(err.Error(), `unknown name "FATAL"`) {
+		t.Errorf("got error %v, want one naming FATAL", err)
+	}
+}
+
+func TestLevelUnmarshalText(t *testing.T) {
+	var l Level
+	if err := l.UnmarshalText([]byte("warn-1")); err != nil {
+		t.Fatal(err)
+	}
+	if l != WarnLevel-1 {
+		t.Errorf("got %s, want %s", l, WarnLevel-1)
+	}
+	data, err := l.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "INFO+3"; got != want {
+		t.Errorf("MarshalText: got %q, want %q", got, want)
+	}
+	if err := l.UnmarshalText([]byte("bad")); err == nil || l != WarnLevel-1 {
+		t.Errorf("got error %v and level %s, want an error and no change", err, l)
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+	type config struct {
+		Level Level
+	}
+	for _, test := range []struct {
+		in   Level
+		want string
+	}{
+		{WarnLevel, `{"Level":"WARN"}`},
+		{InfoLevel + 2, `{"Level":"INFO+2"}`},
+	} {
+		data, err := json.Marshal(config{test.in})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); got != test.want {
+			t.Errorf("%d: got %s, want %s", test.in, got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		in   string
+		want Level
+	}{
+		{`{"Level":"WARN"}`, WarnLevel},
+		{`{"Level":4}`, WarnLevel},
+		{`{"Level":"info+2"}`, InfoLevel + 2},
+		{`{"Level":-3}`, -3},
+		// null leaves the level unchanged.
+		{`{"Level":null}`, ErrorLevel},
+	} {
+		c := config{ErrorLevel}
+		if err := json.Unmarshal([]byte(test.in), &c); err != nil {
+			t.Fatal(err)
+		}
+		if c.Level != test.want {
+			t.Errorf("%s: got %s, want %s", test.in, c.Level, test.want)
+		}
+	}
+
+	for _, in := range []string{`{"Level":"LOUD"}`, `{"Level":true}`, `{"Level":1.5}`} {
+		var c config
+		if err := json.Unmarshal([]byte(in), &c); err == nil {
+			t.Errorf("%s: got nil error", in)
+		}
+	}
+}
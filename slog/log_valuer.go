@@ -0,0 +1,42 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "fmt"
+
+// A LogValuer is any Go value that can convert itself into an Attr value for
+// logging. Handlers call LogValue only for records they output, after
+// checking the level, so LogValuers let expensive values, like copies of big
+// structs, be computed only when needed.
+//
+// The key of the returned Attr is ignored: the value is output with the key
+// of the Attr holding the LogValuer. Use an empty key, as in
+// String("", s) or Group("", as...).
+// If the value is itself a LogValuer, it is resolved in turn.
+// A LogValuer passed to Logger.With is resolved anew for each record.
+type LogValuer interface {
+	LogValue() Attr
+}
+
+// maxLogValues bounds the chain of LogValuers that resolve follows, which
+// could otherwise be a cycle.
+const maxLogValues = 100
+
+// resolve returns a, or, if the value of a is a LogValuer, an Attr with a's
+// key and the value it resolves to. The values of groups are not resolved.
+func (a Attr) resolve() Attr {
+	orig := a
+	for i := 0; i < maxLogValues; i++ {
+		if a.Kind() != AnyKind {
+			return a
+		}
+		lv, ok := a.any.(LogValuer)
+		if !ok {
+			return a
+		}
+		a = lv.LogValue().WithKey(orig.key)
+	}
+	return String(orig.key, fmt.Sprintf("!ERROR:LogValue called too many times on type %T", orig.any))
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type user struct {
+	id    int
+	name  string
+	calls *int
+}
+
+func (u user) LogValue() Attr {
+	*u.calls++
+	return Group("", Int("id", u.id), String("name", u.name))
+}
+
+// wrapped resolves to its value.
+type wrapped struct{ v any }
+
+func (w wrapped) LogValue() Attr { return Any("ignored", w.v) }
+
+// cycle resolves to itself.
+type cycle struct{}
+
+func (c *cycle) LogValue() Attr { return Any("", c) }
+
+func TestLogValuer(t *testing.T) {
+	calls := 0
+	u := user{1, "gopher", &calls}
+	for _, test := range []struct {
+		name     string
+		a        Attr
+		wantText string
+		wantJSON string
+	}{
+		{"group", Any("u", u), `u.id=1 u.name=gopher`, `"u":{"id":1,"name":"gopher"}`},
+		{"chain", Any("w", wrapped{wrapped{u}}), `w.id=1 w.name=gopher`, `"w":{"id":1,"name":"gopher"}`},
+		{"scalar", Any("w", wrapped{3}), `w=3`, `"w":3`},
+		{"in group", Group("g", Any("w", wrapped{"x"})), `g.w=x`, `"g":{"w":"x"}`},
+		{
+			"cycle",
+			Any("c", &cycle{}),
+			`c="!ERROR:LogValue called too many times on type *slog.cycle"`,
+			`"c":"!ERROR:LogValue called too many times on type *slog.cycle"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(test.a)
+			var tbuf, jbuf bytes.Buffer
+			if err := NewTextHandler(&tbuf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewJSONHandler(&jbuf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSuffix(tbuf.String(), "\n"), "level=INFO msg=m "+test.wantText; got != want {
+				t.Errorf("text:\ngot  %s\nwant %s", got, want)
+			}
+			if got, want := strings.TrimSuffix(jbuf.String(), "\n"), `{"level":"INFO","msg":"m",`+test.wantJSON+"}"; got != want {
+				t.Errorf("JSON:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestLogValuerDisabled(t *testing.T) {
+	calls := 0
+	u := user{1, "gopher", &calls}
+	var buf bytes.Buffer
+	l := New(NewTextHandler(&buf))
+	l.LogAt(time.Time{}, DebugLevel, "disabled", "u", u)
+	if calls != 0 {
+		t.Errorf("LogValue called %d times for a disabled record", calls)
+	}
+	l.LogAt(time.Time{}, InfoLevel, "enabled", "u", u)
+	if calls != 1 {
+		t.Errorf("LogValue called %d times, want 1", calls)
+	}
+	// ReplaceAttr sees the resolved value.
+	buf.Reset()
+	h := HandlerOptions{
+		ReplaceAttr: func(_ []string, a Attr) Attr {
+			if a.Key() == "w" && a.Kind() != StringKind {
+				t.Errorf("ReplaceAttr got kind %s, want string", a.Kind())
+			}
+			return a
+		},
+	}.NewTextHandler(&buf)
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(Any("w", wrapped{"x"}))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// counter resolves to the number of times it has been resolved.
+type counter struct{ n *int }
+
+func (c counter) LogValue() Attr {
+	*c.n++
+	return Int("", *c.n)
+}
+
+func TestLogValuerWith(t *testing.T) {
+	// A LogValuer passed to With is resolved for each record, while the
+	// other Attrs are still preformatted.
+	n := 0
+	for _, json := range []bool{false, true} {
+		n = 0
+		var buf bytes.Buffer
+		var h Handler
+		if json {
+			h = NewJSONHandler(&buf)
+		} else {
+			h = NewTextHandler(&buf)
+		}
+		h = h.With([]Attr{String("a", "x")}).WithGroup("g").
+			With([]Attr{Int("b", 1), Any("c", counter{&n}), Int("d", 2)}).
+			With([]Attr{Group("e", Any("f", counter{&n}))})
+		l := New(h)
+		l.LogAt(time.Time{}, InfoLevel, "m")
+		l.LogAt(time.Time{}, InfoLevel, "m", "z", 3)
+		want := "level=INFO msg=m a=x g.b=1 g.c=1 g.d=2 g.e.f=2\n" +
+			"level=INFO msg=m a=x g.b=1 g.c=3 g.d=2 g.e.f=4 g.z=3\n"
+		if json {
+			want = `{"level":"INFO","msg":"m","a":"x","g":{"b":1,"c":1,"d":2,"e":{"f":2}}}` + "\n" +
+				`{"level":"INFO","msg":"m","a":"x","g":{"b":1,"c":3,"d":2,"e":{"f":4},"z":3}}` + "\n"
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("json=%t:\ngot\n%s\nwant\n%s", json, got, want)
+		}
+		var c *commonHandler
+		if json {
+			c = h.(*JSONHandler).commonHandler
+		} else {
+			c = h.(*TextHandler).commonHandler
+		}
+		if got, want := len(c.attrs), 3; got != want {
+			t.Errorf("json=%t: %d Attrs not preformatted, want %d", json, got, want)
+		}
+		if !bytes.Contains(c.preformattedAttrs, []byte("b")) {
+			t.Errorf("json=%t: static Attrs were not preformatted: %q", json, c.preformattedAttrs)
+		}
+	}
+}
+
+// ttlValuer resolves to an Attr created by TTL.
+type ttlValuer struct{}
+
+func (ttlValuer) LogValue() Attr { return TTL(Int("", 1), time.Hour) }
+
+func TestLogValuerChecks(t *testing.T) {
+	// The options that look at values see the resolved value.
+	var buf bytes.Buffer
+	calls := 0
+	opts := HandlerOptions{ErrorObjects: true, KeyMinLevel: map[string]Level{"dropped": DebugLevel}}
+	l := New(opts.NewTextHandler(&buf))
+	l.LogAt(time.Time{}, InfoLevel, "m",
+		"e", wrapped{errors.New("boom")},
+		"t", ttlValuer{},
+		"dropped", user{1, "gopher", &calls})
+	want := `level=INFO msg=m e.message=boom e.type=*errors.errorString t=1 t_ttl=1h0m0s` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+	// Attrs removed by their keys are not resolved.
+	if calls != 0 {
+		t.Errorf("LogValue called %d times for a removed Attr", calls)
+	}
+}
@@ -5,6 +5,8 @@
 package slog
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"sync/atomic"
 	"time"
@@ -55,9 +57,12 @@ func (w *handlerWriter) Write(buf []byte) (int, error) {
 // Log, Debug, Info, Warn, and Error methods.
 // For each call, it creates a Record and passes it to a Handler.
 //
-// Loggers are immutable; to create a new one, call [New] or [Logger.With].
+// Loggers are immutable; to create a new one, call [New], [Logger.With] or
+// [Logger.WithGroup].
 type Logger struct {
 	handler Handler // for structured logging
+	ctx     context.Context
+	attrs   attrGroups // attrs passed to With and groups passed to WithGroup, for Snapshot
 }
 
 // Handler returns l's Handler.
@@ -75,7 +80,51 @@ func (l *Logger) With(args ...any) *Logger {
 		attr, args = argsToAttr(args)
 		attrs = append(attrs, attr)
 	}
-	return &Logger{handler: l.handler.With(attrs)}
+	return &Logger{
+		handler: l.handler.With(attrs),
+		ctx:     l.ctx,
+		// The handler owns attrs, so copy them.
+		attrs: l.attrs.with(attrs),
+	}
+}
+
+// WithGroup returns a new Logger that starts a group. The keys of all
+// attributes added to the Logger, with [Logger.With] or in log calls, will be
+// qualified by the given name. If name is empty, WithGroup returns l.
+func (l *Logger) WithGroup(name string) *Logger {
+	if name == "" {
+		return l
+	}
+	return &Logger{
+		handler: l.handler.WithGroup(name),
+		ctx:     l.ctx,
+		attrs:   l.attrs.withGroup(name),
+	}
+}
+
+// Snapshot returns a Record with the current time, the given level and
+// message, and the attributes accumulated by calls to [Logger.With], without
+// passing it to l's handler. Attributes added after a call to
+// [Logger.WithGroup] are in a Group. The Record's source line is that of the
+// caller of Snapshot.
+func (l *Logger) Snapshot(level Level, msg string) Record {
+	r := NewRecord(time.Now(), level, msg, 3)
+	r.ctx = l.ctx
+	r.AddAttrs(l.attrs.all(nil)...)
+	return r
+}
+
+// Context returns l's context, or nil if it has none.
+func (l *Logger) Context() context.Context { return l.ctx }
+
+// WithContext returns a new Logger with the same handler
+// as the receiver and the given context.
+// The context is available to handlers from the [Record.Context]
+// method of the records the Logger produces.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	l2 := *l
+	l2.ctx = ctx
+	return &l2
 }
 
 // New creates a new Logger with the given Handler.
@@ -127,7 +176,22 @@ func (l *Logger) makeRecord(msg string, level Level, depth int) Record {
 	if useSourceLine {
 		depth += 5
 	}
-	return NewRecord(time.Now(), level, msg, depth)
+	r := NewRecord(time.Now(), level, msg, depth)
+	r.ctx = l.ctx
+	return r
+}
+
+// LogAt is like [Logger.Log], but uses t as the time of the log record
+// instead of the current time. It is useful for replaying historical events.
+func (l *Logger) LogAt(t time.Time, level Level, msg string, args ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	// LogAt is one frame closer to makeRecord than LogDepth.
+	r := l.makeRecord(msg, level, -1)
+	r.time = t
+	r.setAttrsFromArgs(args)
+	_ = l.Handler().Handle(r)
 }
 
 // LogAttrs is a more efficient version of [Logger.Log] that accepts only Attrs.
@@ -172,6 +236,42 @@ func (l *Logger) Error(msg string, err error, args ...any) {
 	l.LogDepth(0, ErrorLevel, msg, args...)
 }
 
+// Logf logs at the given level a message formatted with fmt.Sprintf.
+// The message is formatted only if l emits records at the level, so
+// disabled calls cost no more than the level check.
+func (l *Logger) Logf(level Level, format string, args ...any) {
+	l.logfDepth(0, level, format, args...)
+}
+
+// Debugf logs at DebugLevel a message formatted as in [Logger.Logf].
+func (l *Logger) Debugf(format string, args ...any) {
+	l.logfDepth(0, DebugLevel, format, args...)
+}
+
+// Infof logs at InfoLevel a message formatted as in [Logger.Logf].
+func (l *Logger) Infof(format string, args ...any) {
+	l.logfDepth(0, InfoLevel, format, args...)
+}
+
+// Warnf logs at WarnLevel a message formatted as in [Logger.Logf].
+func (l *Logger) Warnf(format string, args ...any) {
+	l.logfDepth(0, WarnLevel, format, args...)
+}
+
+// Errorf logs at ErrorLevel a message formatted as in [Logger.Logf].
+func (l *Logger) Errorf(format string, args ...any) {
+	l.logfDepth(0, ErrorLevel, format, args...)
+}
+
+// logfDepth formats the message and logs it, if l is enabled at level.
+// It must not retain args, so that callers need not allocate them.
+func (l *Logger) logfDepth(calldepth int, level Level, format string, args ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.LogAttrsDepth(calldepth+1, level, fmt.Sprintf(format, args...))
+}
+
 // Debug calls Logger.Debug on the default logger.
 func Debug(msg string, args ...any) {
 	Default().LogDepth(0, DebugLevel, msg, args...)
@@ -196,6 +296,26 @@ func Error(msg string, err error, args ...any) {
 	Default().LogDepth(0, ErrorLevel, msg, args...)
 }
 
+// Debugf calls Logger.Debugf on the default logger.
+func Debugf(format string, args ...any) {
+	Default().logfDepth(0, DebugLevel, format, args...)
+}
+
+// Infof calls Logger.Infof on the default logger.
+func Infof(format string, args ...any) {
+	Default().logfDepth(0, InfoLevel, format, args...)
+}
+
+// Warnf calls Logger.Warnf on the default logger.
+func Warnf(format string, args ...any) {
+	Default().logfDepth(0, WarnLevel, format, args...)
+}
+
+// Errorf calls Logger.Errorf on the default logger.
+func Errorf(format string, args ...any) {
+	Default().logfDepth(0, ErrorLevel, format, args...)
+}
+
 // Log calls Logger.Log on the default logger.
 func Log(level Level, msg string, args ...any) {
 	Default().LogDepth(0, level, msg, args...)
@@ -205,3 +325,16 @@ func Log(level Level, msg string, args ...any) {
 func LogAttrs(level Level, msg string, attrs ...Attr) {
 	Default().LogAttrsDepth(0, level, msg, attrs...)
 }
+
+// Timed returns a function that, when called, logs msg at InfoLevel
+// with l, adding an Attr with key "elapsed" holding the time since Timed was
+// called. It is intended to be deferred:
+//
+//	defer slog.Timed(logger, "operation")()
+func Timed(l *Logger, msg string, args ...any) func() {
+	start := time.Now()
+	return func() {
+		elapsed := Duration("elapsed", time.Since(start))
+		l.LogDepth(0, InfoLevel, msg, append(args[:len(args):len(args)], elapsed)...)
+	}
+}
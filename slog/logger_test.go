@@ -6,6 +6,7 @@ package slog
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"log"
 	"path/filepath"
@@ -14,6 +15,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
 const timeRE = `\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}(Z|-\d{2}:\d{2})`
@@ -153,6 +156,16 @@ func TestCallDepth(t *testing.T) {
 	check(10)
 	LogAttrs(InfoLevel, "")
 	check(11)
+	logger.Infof("")
+	check(12)
+	logger.Logf(InfoLevel, "")
+	check(13)
+	Infof("")
+	check(14)
+	Errorf("")
+	check(15)
+	logger.SampledLog("k", 1, InfoLevel, "")
+	check(16)
 }
 
 func TestAlloc(t *testing.T) {
@@ -220,6 +233,10 @@ func TestAlloc(t *testing.T) {
 	t.Run("pairs", func(t *testing.T) {
 		wantAllocs(t, 0, func() { dl.Info("", "error", io.EOF) })
 	})
+	t.Run("Infof disabled", func(t *testing.T) {
+		l := New(discardHandler{disabled: true})
+		wantAllocs(t, 0, func() { l.Infof("hello %s %d", "abc", 2000) })
+	})
 	t.Run("attrs1", func(t *testing.T) {
 		wantAllocs(t, 0, func() { dl.LogAttrs(InfoLevel, "", Int("a", 1)) })
 		wantAllocs(t, 0, func() { dl.LogAttrs(InfoLevel, "", Any("error", io.EOF)) })
@@ -252,6 +269,62 @@ func TestAlloc(t *testing.T) {
 	})
 }
 
+// countingStringer counts the calls to its String method.
+type countingStringer struct{ calls *int }
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return "s"
+}
+
+func TestLogf(t *testing.T) {
+	var buf bytes.Buffer
+	var calls int
+	l := New(NewTextHandler(&buf)).With("a", 1)
+
+	check := func(want string) {
+		t.Helper()
+		if want != "" {
+			want = "time=" + timeRE + " " + want
+		}
+		checkLogOutput(t, buf.String(), want)
+		buf.Reset()
+	}
+
+	// The arguments of disabled calls are not formatted.
+	l.Debugf("%v", countingStringer{&calls})
+	check("")
+	if calls != 0 {
+		t.Errorf("disabled: String called %d times", calls)
+	}
+
+	l.Infof("%d %s %v", 1, "x", countingStringer{&calls})
+	check(`level=INFO msg="1 x s" a=1`)
+	if calls != 1 {
+		t.Errorf("enabled: String called %d times, want 1", calls)
+	}
+
+	l.Warnf("w%d", 2)
+	check(`level=WARN msg=w2 a=1`)
+
+	l.Errorf("wrap: %v", io.EOF)
+	check(`level=ERROR msg="wrap: EOF" a=1`)
+
+	l.Logf(WarnLevel+1, "%s", "custom")
+	check(`level=WARN\+1 msg=custom a=1`)
+}
+
+func BenchmarkLogfDisabled(b *testing.B) {
+	var calls int
+	l := New(discardHandler{disabled: true})
+	arg := countingStringer{&calls}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Infof("%v %d", arg, 1)
+	}
+	b.ReportMetric(float64(calls)/float64(b.N), "formats/op")
+}
+
 func TestSetAttrs(t *testing.T) {
 	for _, test := range []struct {
 		args []any
@@ -295,8 +368,9 @@ func clean(s string) string {
 }
 
 type captureHandler struct {
-	r     Record
-	attrs []Attr
+	r      Record
+	attrs  []Attr
+	groups []string
 }
 
 func (h *captureHandler) Handle(r Record) error {
@@ -312,6 +386,12 @@ func (c *captureHandler) With(as []Attr) Handler {
 	return &c2
 }
 
+func (c *captureHandler) WithGroup(name string) Handler {
+	c2 := *c
+	c2.groups = append(slices.Clip(c2.groups), name)
+	return &c2
+}
+
 type discardHandler struct {
 	disabled bool
 	attrs    []Attr
@@ -323,6 +403,7 @@ func (d discardHandler) With(as []Attr) Handler {
 	d.attrs = concat(d.attrs, as)
 	return d
 }
+func (d discardHandler) WithGroup(string) Handler { return d }
 
 // This is a simple benchmark. See the benchmarks subdirectory for more extensive ones.
 func BenchmarkNopLog(b *testing.B) {
@@ -347,3 +428,106 @@ func BenchmarkNopLog(b *testing.B) {
 		}
 	})
 }
+
+func TestTimed(t *testing.T) {
+	h := &captureHandler{}
+	l := New(h)
+	var startLine, endLine int
+	timed := func() {
+		_, _, startLine, _ = runtime.Caller(0)
+		defer Timed(l, "op", "a", 1)()
+		time.Sleep(time.Millisecond)
+		_, _, endLine, _ = runtime.Caller(0)
+	}
+	timed()
+	// The source is in the timed function, not in its caller.
+	if file, line := h.r.SourceLine(); filepath.Base(file) != "logger_test.go" || line <= startLine || line > endLine+1 {
+		t.Errorf("got source %s:%d, want logger_test.go:%d-%d", file, line, startLine+1, endLine+1)
+	}
+	if got, want := h.r.Message(), "op"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+	as := attrsSlice(h.r)
+	if len(as) != 2 || !as[0].Equal(Int("a", 1)) || as[1].Key() != "elapsed" {
+		t.Fatalf("got %v, want a=1 and elapsed", as)
+	}
+	if d := as[1].Duration(); d < time.Millisecond {
+		t.Errorf("got elapsed %s, want at least 1ms", d)
+	}
+}
+
+func TestLoggerContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "v")
+	h := &captureHandler{}
+	l := New(h)
+	if l.Context() != nil {
+		t.Error("got non-nil context, want nil")
+	}
+	if got := l.WithContext(ctx).With("a", 1).Context(); got != ctx {
+		t.Errorf("With: got %v, want %v", got, ctx)
+	}
+	l.WithContext(ctx).Info("m")
+	if got := h.r.Context().Value(key{}); got != "v" {
+		t.Errorf("got %v, want v", got)
+	}
+	l.Info("m")
+	if got := h.r.Context(); got != context.Background() {
+		t.Errorf("got %v, want Background", got)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	h := &captureHandler{}
+	l := New(h).With("a", 1).With("b", 2, String("c", "three"))
+	r := l.With("d", 4).Snapshot(WarnLevel, "snap")
+	if got, want := r.Message(), "snap"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+	if got, want := r.Level(), WarnLevel; got != want {
+		t.Errorf("got level %s, want %s", got, want)
+	}
+	want := []Attr{Int("a", 1), Int("b", 2), String("c", "three"), Int("d", 4)}
+	if got := attrsSlice(r); !attrsEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if file, _ := r.SourceLine(); filepath.Base(file) != "logger_test.go" {
+		t.Errorf("got file %q, want logger_test.go", file)
+	}
+	if h.r.Message() != "" {
+		t.Error("Snapshot called the handler")
+	}
+}
+
+func TestLoggerWithGroup(t *testing.T) {
+	h := &captureHandler{}
+	l := New(h).With("a", 1).WithGroup("g").With("b", 2).WithGroup("h")
+	if got, want := l.Handler().(*captureHandler).groups, []string{"g", "h"}; !slices.Equal(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+	if l.WithGroup("") != l {
+		t.Error("WithGroup with an empty name returned a new Logger")
+	}
+	r := l.With("c", 3).Snapshot(InfoLevel, "m")
+	want := []Attr{Int("a", 1), Group("g", Int("b", 2), Group("h", Int("c", 3)))}
+	if got := attrsSlice(r); !attrsEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLogAt(t *testing.T) {
+	h := &captureHandler{}
+	l := New(h)
+	l.LogAt(testTime, WarnLevel, "m", "a", 1)
+	_, _, wantLine, _ := runtime.Caller(0)
+	if got := h.r.Time(); !got.Equal(testTime) {
+		t.Errorf("got time %s, want %s", got, testTime)
+	}
+	if got, want := attrsSlice(h.r), []Attr{Int("a", 1)}; !attrsEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	file, line := h.r.SourceLine()
+	if filepath.Base(file) != "logger_test.go" || line != wantLine-1 {
+		t.Errorf("got source %s:%d, want logger_test.go:%d", file, line, wantLine-1)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "strings"
+
+// MultiHandler returns a Handler that passes records to each of handlers,
+// as when writing records to standard error and also sending them to a
+// remote service.
+//
+// The handler is enabled at a level if any of handlers is. Each record is
+// passed to those of handlers that are enabled at its level, in order; each
+// gets its own clone of the record. If some of them fail, the others are
+// still called, and Handle returns an error that combines those returned.
+// With and WithGroup apply to all of handlers.
+func MultiHandler(handlers ...Handler) Handler {
+	return &multiHandler{handlers: concat(handlers, nil)}
+}
+
+type multiHandler struct {
+	handlers []Handler
+}
+
+func (h *multiHandler) Enabled(l Level) bool {
+	for _, c := range h.handlers {
+		if c.Enabled(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *multiHandler) Handle(r Record) error {
+	var errs multiError
+	for _, c := range h.handlers {
+		if !c.Enabled(r.Level()) {
+			continue
+		}
+		if err := c.Handle(r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (h *multiHandler) With(attrs []Attr) Handler {
+	hs := make([]Handler, len(h.handlers))
+	for i, c := range h.handlers {
+		// Each handler owns its slice.
+		hs[i] = c.With(concat(attrs, nil))
+	}
+	return &multiHandler{handlers: hs}
+}
+
+func (h *multiHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	hs := make([]Handler, len(h.handlers))
+	for i, c := range h.handlers {
+		hs[i] = c.WithGroup(name)
+	}
+	return &multiHandler{handlers: hs}
+}
+
+// A multiError combines the errors returned by the handlers of a
+// multiHandler.
+type multiError []error
+
+func (e multiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As in Go 1.20 and later.
+func (e multiError) Unwrap() []error { return e }
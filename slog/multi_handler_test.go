@@ -0,0 +1,92 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingHandler struct {
+	discardHandler
+	err   error
+	calls *int
+}
+
+func (h failingHandler) Handle(Record) error {
+	*h.calls++
+	return h.err
+}
+
+func TestMultiHandler(t *testing.T) {
+	var jbuf, tbuf bytes.Buffer
+	h := MultiHandler(
+		HandlerOptions{Level: WarnLevel}.NewJSONHandler(&jbuf),
+		HandlerOptions{Level: DebugLevel}.NewTextHandler(&tbuf),
+	)
+	// Enabled reflects the most permissive handler.
+	for _, test := range []struct {
+		level Level
+		want  bool
+	}{
+		{DebugLevel - 1, false},
+		{DebugLevel, true},
+		{InfoLevel, true},
+		{ErrorLevel, true},
+	} {
+		if got := h.Enabled(test.level); got != test.want {
+			t.Errorf("Enabled(%s) = %t, want %t", test.level, got, test.want)
+		}
+	}
+
+	l := New(h).With("a", 1).WithGroup("g").With("b", 2)
+	l.LogAt(time.Time{}, WarnLevel, "w", "c", 3)
+	l.LogAt(time.Time{}, InfoLevel, "i", "c", 4)
+	wantJSON := `{"level":"WARN","msg":"w","a":1,"g":{"b":2,"c":3}}`
+	wantText := []string{
+		`level=WARN msg=w a=1 g.b=2 g.c=3`,
+		`level=INFO msg=i a=1 g.b=2 g.c=4`,
+	}
+	if got := strings.TrimSuffix(jbuf.String(), "\n"); got != wantJSON {
+		t.Errorf("JSON:\ngot  %s\nwant %s", got, wantJSON)
+	}
+	if got := strings.TrimSuffix(tbuf.String(), "\n"); got != strings.Join(wantText, "\n") {
+		t.Errorf("text:\ngot  %s\nwant %s", got, strings.Join(wantText, "\n"))
+	}
+}
+
+func TestMultiHandlerErrors(t *testing.T) {
+	err1, err2 := errors.New("e1"), errors.New("e2")
+	var calls [3]int
+	h := MultiHandler(
+		failingHandler{err: err1, calls: &calls[0]},
+		failingHandler{calls: &calls[1]},
+		failingHandler{err: err2, calls: &calls[2]},
+	)
+	err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0))
+	if calls != [3]int{1, 1, 1} {
+		t.Errorf("got calls %v, want each handler called once", calls)
+	}
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+	if got, want := err.Error(), "e1\ne2"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Check the elements directly: errors.Is matches them only in Go 1.20
+	// and later.
+	if errs, ok := err.(multiError); !ok || len(errs) != 2 || errs[0] != err1 || errs[1] != err2 {
+		t.Errorf("got %#v, want a multiError of both errors", err)
+	}
+
+	// Disabled handlers are not called.
+	h = MultiHandler(failingHandler{discardHandler: discardHandler{disabled: true}, err: err1, calls: &calls[0]})
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil || calls[0] != 1 {
+		t.Errorf("got error %v and %d calls, want nil and 1", err, calls[0])
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "io"
+
+// MultiFormatHandler is a Handler that writes each Record both as JSON, like
+// [JSONHandler], and as text, like [TextHandler], as when writing to a log
+// file for machines and to a terminal for people.
+//
+// Unlike a [MultiHandler] of a JSONHandler and a TextHandler, it resolves
+// the Attrs of each Record once and renders the result in both formats:
+// the LogValue methods of [LogValuer] values, [HandlerOptions.ReplaceAttr]
+// and the [HandlerOptions.Transforms] are called once per Attr, and both
+// outputs show the values they returned.
+// That saves work when they are expensive, and keeps the outputs
+// consistent when they are not deterministic. When the AutoNest option is
+// set, the JSON output nests Attrs in a different order, so they are
+// resolved separately for each format, as they are when options make the
+// formats preformat different Attrs passed to With.
+type MultiFormatHandler struct {
+	handlers []*commonHandler // the JSON handler, then the text handler
+}
+
+// NewMultiFormatHandler creates a MultiFormatHandler that writes JSON to
+// jsonW and text to textW, using the default options. Either writer may be
+// nil, in which case that format is not written.
+func NewMultiFormatHandler(jsonW, textW io.Writer) *MultiFormatHandler {
+	return (HandlerOptions{}).NewMultiFormatHandler(jsonW, textW)
+}
+
+// NewMultiFormatHandler creates a MultiFormatHandler with the given options
+// that writes JSON to jsonW and text to textW. Either writer may be nil, in
+// which case that format is not written.
+func (opts HandlerOptions) NewMultiFormatHandler(jsonW, textW io.Writer) *MultiFormatHandler {
+	h := &MultiFormatHandler{}
+	if jsonW != nil {
+		h.handlers = append(h.handlers, opts.NewJSONHandler(jsonW).commonHandler)
+	}
+	if textW != nil {
+		h.handlers = append(h.handlers, opts.NewTextHandler(textW).commonHandler)
+	}
+	return h
+}
+
+// Enabled reports whether l is greater than or equal to the
+// minimum level.
+func (h *MultiFormatHandler) Enabled(l Level) bool {
+	return len(h.handlers) > 0 && h.handlers[0].Enabled(l)
+}
+
+// With returns a new MultiFormatHandler whose attributes consists
+// of h's attributes followed by attrs.
+func (h *MultiFormatHandler) With(attrs []Attr) Handler {
+	h2 := &MultiFormatHandler{handlers: make([]*commonHandler, len(h.handlers))}
+	for i, c := range h.handlers {
+		h2.handlers[i] = c.with(attrs)
+	}
+	return h2
+}
+
+// WithGroup returns a new MultiFormatHandler whose later attributes are in
+// the group with the given name, in both formats.
+func (h *MultiFormatHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := &MultiFormatHandler{handlers: make([]*commonHandler, len(h.handlers))}
+	for i, c := range h.handlers {
+		h2.handlers[i] = c.withGroup(name)
+	}
+	return h2
+}
+
+// Handle writes r as JSON and then as text, as described by
+// [JSONHandler.Handle] and [TextHandler.Handle].
+// If writing fails for one format, the other is still written,
+// and Handle returns an error that combines the errors.
+func (h *MultiFormatHandler) Handle(r Record) error {
+	var reps *replacements
+	if h.sharesReplacements() {
+		reps = &replacements{}
+	}
+	var errs multiError
+	for _, c := range h.handlers {
+		if reps != nil {
+			reps.next = 0
+		}
+		if err := c.handleReplaced(r, reps); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// sharesReplacements reports whether the handlers replace the same Attrs
+// in the same order, so that they can share the results. That is not so if
+// they preformat different Attrs from With, or nest them differently.
+func (h *MultiFormatHandler) sharesReplacements() bool {
+	if len(h.handlers) < 2 || h.handlers[0].opts.AutoNest {
+		return false
+	}
+	c0 := h.handlers[0]
+	for _, c := range h.handlers[1:] {
+		if c.canPreformat() != c0.canPreformat() || len(c.attrs) != len(c0.attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+// replacements records the results of replacing the Attrs of a record,
+// so that handlers with the same options that output the record in
+// different formats need not replace them again. Such handlers replace
+// the same Attrs in the same order.
+type replacements struct {
+	attrs []Attr
+	oks   []bool
+	next  int // index of the result to return next
+}
+
+// replace returns the next recorded result, or, if there is none, records
+// and returns the result of calling f on a.
+func (r *replacements) replace(a Attr, f func(Attr) (Attr, bool)) (Attr, bool) {
+	if r.next < len(r.attrs) {
+		i := r.next
+		r.next++
+		return r.attrs[i], r.oks[i]
+	}
+	a, ok := f(a)
+	r.attrs = append(r.attrs, a)
+	r.oks = append(r.oks, ok)
+	r.next++
+	return a, ok
+}
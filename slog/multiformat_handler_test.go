@@ -0,0 +1,159 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiFormatHandler(t *testing.T) {
+	var jbuf, tbuf bytes.Buffer
+	calls := 0
+	opts := HandlerOptions{
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key() == "n" {
+				// A value that differs each time it is resolved.
+				calls++
+				return Int("n", calls)
+			}
+			return a
+		},
+	}
+	h := opts.NewMultiFormatHandler(&jbuf, &tbuf)
+	l := New(h).With("a", 1).WithGroup("g")
+	ucalls := 0
+	u := user{1, "gopher", &ucalls}
+	l.LogAt(time.Time{}, InfoLevel, "m", "n", 0, Group("h", Int("n", 0)), "u", u)
+	l.LogAt(time.Time{}, DebugLevel, "disabled", "n", 0, "u", u)
+	if calls != 2 {
+		t.Errorf("ReplaceAttr called %d times for n, want 2", calls)
+	}
+	if ucalls != 1 {
+		t.Errorf("LogValue called %d times, want 1", ucalls)
+	}
+	wantJSON := `{"level":"INFO","msg":"m","a":1,"g":{"n":1,"h":{"n":2},"u":{"id":1,"name":"gopher"}}}`
+	wantText := `level=INFO msg=m a=1 g.n=1 g.h.n=2 g.u.id=1 g.u.name=gopher`
+	if got := strings.TrimSuffix(jbuf.String(), "\n"); got != wantJSON {
+		t.Errorf("JSON:\ngot  %s\nwant %s", got, wantJSON)
+	}
+	if got := strings.TrimSuffix(tbuf.String(), "\n"); got != wantText {
+		t.Errorf("text:\ngot  %s\nwant %s", got, wantText)
+	}
+}
+
+func TestMultiFormatHandlerOptions(t *testing.T) {
+	// The output matches that of separate JSON and text handlers, even with
+	// options that make the handlers preformat different Attrs.
+	for _, test := range []struct {
+		name string
+		opts HandlerOptions
+	}{
+		{"FieldOrder", HandlerOptions{FieldOrder: []string{"msg"}}},
+		{"KeyMinLevel", HandlerOptions{KeyMinLevel: map[string]Level{"c": WarnLevel}}},
+		{"both", HandlerOptions{FieldOrder: []string{"msg", "c"}, KeyMinLevel: map[string]Level{"b": WarnLevel}}},
+	} {
+		opts := test.opts
+		var jbuf, tbuf, wantJSON, wantText bytes.Buffer
+		with := func(h Handler) *Logger {
+			return New(h).With("a", 1).WithGroup("g").With("b", 2)
+		}
+		for _, l := range []*Logger{
+			with(opts.NewMultiFormatHandler(&jbuf, &tbuf)),
+			with(opts.NewJSONHandler(&wantJSON)),
+			with(opts.NewTextHandler(&wantText)),
+		} {
+			l.LogAt(time.Time{}, InfoLevel, "m", "b", 2, "c", "x")
+			l.LogAt(time.Time{}, WarnLevel, "m", "c", "y")
+		}
+		if got, want := jbuf.String(), wantJSON.String(); got != want {
+			t.Errorf("%s: JSON:\ngot\n%s\nwant\n%s", test.name, got, want)
+		}
+		if got, want := tbuf.String(), wantText.String(); got != want {
+			t.Errorf("%s: text:\ngot\n%s\nwant\n%s", test.name, got, want)
+		}
+	}
+}
+
+func TestMultiFormatHandlerFieldOrder(t *testing.T) {
+	calls := 0
+	opts := HandlerOptions{
+		FieldOrder: []string{"msg", "n", "level"},
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key() == "n" {
+				calls++
+				return Int("n", calls)
+			}
+			return a
+		},
+	}
+	var jbuf, tbuf bytes.Buffer
+	l := New(opts.NewMultiFormatHandler(&jbuf, &tbuf)).With("a", 1, "n", 0)
+	l.LogAt(time.Time{}, InfoLevel, "m", "b", 2)
+	// Both formats share the one replacement of n.
+	if calls != 1 {
+		t.Errorf("ReplaceAttr called %d times for n, want 1", calls)
+	}
+	wantJSON := `{"msg":"m","n":1,"level":"INFO","a":1,"b":2}`
+	wantText := `msg=m n=1 level=INFO a=1 b=2`
+	if got := strings.TrimSuffix(jbuf.String(), "\n"); got != wantJSON {
+		t.Errorf("JSON:\ngot  %s\nwant %s", got, wantJSON)
+	}
+	if got := strings.TrimSuffix(tbuf.String(), "\n"); got != wantText {
+		t.Errorf("text:\ngot  %s\nwant %s", got, wantText)
+	}
+}
+
+func TestMultiFormatHandlerOneFormat(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewMultiFormatHandler(nil, &buf)
+	if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "level=INFO msg=m\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if NewMultiFormatHandler(nil, nil).Enabled(ErrorLevel) {
+		t.Error("handler with no writers is enabled")
+	}
+}
+
+func BenchmarkMultiFormatHandler(b *testing.B) {
+	calls := 0
+	opts := HandlerOptions{
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key() == "expensive" {
+				calls++
+				// Stand in for an expensive computation.
+				return String(a.Key(), strings.Repeat(a.str(), 100))
+			}
+			return a
+		},
+	}
+	for _, test := range []struct {
+		name string
+		h    Handler
+	}{
+		{"MultiFormatHandler", opts.NewMultiFormatHandler(io.Discard, io.Discard)},
+		{"MultiHandler", MultiHandler(opts.NewJSONHandler(io.Discard), opts.NewTextHandler(io.Discard))},
+	} {
+		b.Run(test.name, func(b *testing.B) {
+			r := NewRecord(time.Time{}, InfoLevel, "msg", 0)
+			r.AddAttrs(String("expensive", "value"), Int("n", 1))
+			calls = 0
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := test.h.Handle(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(calls)/float64(b.N), "resolutions/op")
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "strings"
+
+// appendNested appends the Attrs in s.nested, with keys containing dots
+// nested into objects as described at [HandlerOptions.AutoNest].
+func (s *handleState) appendNested() {
+	as := make([]Attr, 0, len(s.nested))
+	for i, a := range s.nested {
+		if s.nestedOrigins != nil {
+			s.origin = s.nestedOrigins[i]
+		}
+		a, ok := s.prepare(a)
+		if !ok {
+			continue
+		}
+		as = append(as, a)
+		if checkSchemas {
+			if m := schemaMismatch(a); m != "" {
+				as = append(as, String(schemaKey, m))
+			}
+		}
+	}
+	if len(as) > 0 {
+		s.openHandlerGroups()
+	}
+	s.appendNestedLevel(as)
+}
+
+// appendNestedLevel appends the Attrs of one level of nesting,
+// whose keys are relative to that level.
+func (s *handleState) appendNestedLevel(as []Attr) {
+	scalars := map[string]bool{}
+	for _, a := range as {
+		if strings.IndexByte(a.Key(), '.') < 0 {
+			scalars[a.Key()] = true
+		}
+	}
+	done := map[string]bool{}
+	for i, a := range as {
+		prefix, _, found := strings.Cut(a.Key(), ".")
+		if !found || scalars[prefix] {
+			s.appendKeyValue(a)
+			continue
+		}
+		if done[prefix] {
+			continue
+		}
+		done[prefix] = true
+		// Collect this and all later Attrs with the same prefix.
+		var group []Attr
+		for _, b := range as[i:] {
+			if p, rest, ok := strings.Cut(b.Key(), "."); ok && p == prefix {
+				group = append(group, b.WithKey(rest))
+			}
+		}
+		s.appendKey(prefix)
+		s.buf.WriteByte('{')
+		s.sep = false
+		s.appendNestedLevel(group)
+		s.buf.WriteByte('}')
+		s.sep = true
+	}
+}
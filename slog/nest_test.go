@@ -0,0 +1,78 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoNest(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		with  []Attr
+		attrs []Attr
+		want  string
+	}{
+		{
+			"same prefix",
+			nil,
+			[]Attr{String("http.method", "GET"), Int("a", 1), Int("http.status", 200)},
+			`"http":{"method":"GET","status":200},"a":1`,
+		},
+		{
+			"deep",
+			[]Attr{String("x.y.z", "1")},
+			[]Attr{String("x.y.w", "2"), String("x.v", "3")},
+			`"x":{"y":{"z":"1","w":"2"},"v":"3"}`,
+		},
+		{
+			"collision",
+			nil,
+			[]Attr{String("http.method", "GET"), Int("http", 1), String("db.name", "d")},
+			`"http.method":"GET","http":1,"db":{"name":"d"}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := HandlerOptions{AutoNest: true}.NewJSONHandler(&buf).With(test.with)
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(test.attrs...)
+			if err := h.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.TrimSuffix(buf.String(), "\n")
+			want := `{"level":"INFO","msg":"m",` + test.want + `}`
+			if got != want {
+				t.Errorf("\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestAutoNestReplace(t *testing.T) {
+	var buf bytes.Buffer
+	opts := HandlerOptions{
+		AutoNest: true,
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key() == "method" {
+				return a.WithKey("http.method")
+			}
+			return a
+		},
+	}
+	r := NewRecord(time.Time{}, InfoLevel, "m.x", 0)
+	r.AddAttrs(String("method", "GET"), Int("http.status", 200))
+	if err := opts.NewJSONHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.TrimSuffix(buf.String(), "\n")
+	want := `{"level":"INFO","msg":"m.x","http":{"method":"GET","status":200}}`
+	if got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
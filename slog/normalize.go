@@ -0,0 +1,47 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "strings"
+
+// NormalizeOptions describe how handlers normalize string values.
+// See [HandlerOptions.NormalizeValues].
+type NormalizeOptions struct {
+	// TrimSpace removes leading and trailing white space, as with
+	// strings.TrimSpace.
+	TrimSpace bool
+
+	// ToLower maps letters to lower case, as with strings.ToLower.
+	ToLower bool
+
+	// If non-nil, Keys reports whether the values of attributes with the
+	// given key are normalized, so that case-sensitive values such as IDs
+	// can be left alone. The key of an attribute in a group is not
+	// qualified by the group's key. If Keys is nil, all values are
+	// normalized.
+	Keys func(key string) bool
+}
+
+// normalize returns a, which has kind StringKind, with its value
+// normalized according to opts.
+func (opts NormalizeOptions) normalize(a Attr) Attr {
+	if !opts.TrimSpace && !opts.ToLower {
+		return a
+	}
+	if opts.Keys != nil && !opts.Keys(a.Key()) {
+		return a
+	}
+	v := a.str()
+	if opts.TrimSpace {
+		v = strings.TrimSpace(v)
+	}
+	if opts.ToLower {
+		v = strings.ToLower(v)
+	}
+	if v == a.str() {
+		return a
+	}
+	return String(a.Key(), v)
+}
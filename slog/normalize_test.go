@@ -0,0 +1,65 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerNormalizeValues(t *testing.T) {
+	r := NewRecord(time.Time{}, InfoLevel, " Msg ", 0)
+	r.AddAttrs(
+		String("email", "  Gopher@Example.COM "),
+		String("token", " AbC "),
+		Group("user", String("email", "X@Y.z")),
+		String("blank", "   "),
+	)
+	for _, test := range []struct {
+		name string
+		opts NormalizeOptions
+		want string
+	}{
+		{
+			"none",
+			NormalizeOptions{},
+			`level=INFO msg=" Msg " email="  Gopher@Example.COM " token=" AbC " user.email=X@Y.z blank="   "`,
+		},
+		{
+			"all",
+			NormalizeOptions{TrimSpace: true, ToLower: true},
+			`level=INFO msg=" Msg " email=gopher@example.com token=abc user.email=x@y.z`,
+		},
+		{
+			"TrimSpace",
+			NormalizeOptions{TrimSpace: true},
+			`level=INFO msg=" Msg " email=Gopher@Example.COM token=AbC user.email=X@Y.z`,
+		},
+		{
+			"email only",
+			NormalizeOptions{
+				TrimSpace: true,
+				ToLower:   true,
+				Keys:      func(key string) bool { return key == "email" },
+			},
+			`level=INFO msg=" Msg " email=gopher@example.com token=" AbC " user.email=x@y.z blank="   "`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			// With OmitEmpty, values that are empty after normalization
+			// are omitted.
+			opts := HandlerOptions{NormalizeValues: test.opts, OmitEmpty: true}
+			if err := opts.NewTextHandler(&buf).Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
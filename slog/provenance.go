@@ -0,0 +1,57 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+// provenanceKey is the key of the group output by
+// [HandlerOptions.AttrProvenance].
+const provenanceKey = "_provenance"
+
+// setOrigin records that the Attrs appended next come from origin,
+// if the handler tracks provenance.
+func (s *handleState) setOrigin(origin string) {
+	if s.h.opts.AttrProvenance {
+		s.origin = origin
+	}
+}
+
+// addProvenance records the origin of an Attr a that replacement turned into r,
+// and whether r is output.
+func (s *handleState) addProvenance(a, r Attr, ok bool) {
+	origin := s.origin
+	key := r.Key()
+	switch {
+	case !ok:
+		key = a.Key()
+		origin += "+drop"
+	case attrChanged(a, r):
+		origin += "+replace"
+	}
+	s.provenance = append(s.provenance, String(key, origin))
+}
+
+// appendProvenance appends the provenance group. Its Attrs are not subject to
+// replacement.
+func (s *handleState) appendProvenance() {
+	prefix := s.openGroup(provenanceKey)
+	for _, a := range s.provenance {
+		s.appendKey(a.Key())
+		s.appendString(a.str())
+	}
+	s.closeGroup(prefix)
+}
+
+// attrChanged reports whether a and b differ. Values of kind AnyKind that
+// cannot be compared are considered equal if they have the same type.
+func attrChanged(a, b Attr) (changed bool) {
+	if a.Kind() != b.Kind() {
+		return true
+	}
+	defer func() {
+		if recover() != nil {
+			changed = false // incomparable values
+		}
+	}()
+	return !a.Equal(b)
+}
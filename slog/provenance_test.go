@@ -0,0 +1,85 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttrProvenance(t *testing.T) {
+	replace := func(_ []string, a Attr) Attr {
+		switch a.Key() {
+		case "secret":
+			return String("secret", "REDACTED")
+		case "drop":
+			return Attr{}
+		}
+		return a
+	}
+	ctxAttrs := func(context.Context) []Attr { return []Attr{String("trace", "t")} }
+	for _, test := range []struct {
+		name string
+		opts HandlerOptions
+		json bool
+		want string
+	}{
+		{
+			"text",
+			HandlerOptions{AttrProvenance: true},
+			false,
+			"level=INFO msg=m a=1 b=2 secret=s drop=d _provenance.a=with _provenance.b=call _provenance.secret=call _provenance.drop=call",
+		},
+		{
+			"replace",
+			HandlerOptions{AttrProvenance: true, ReplaceAttr: replace, ContextExtractors: []ContextExtractor{ctxAttrs}},
+			false,
+			"level=INFO msg=m a=1 trace=t b=2 secret=REDACTED " +
+				"_provenance.a=with _provenance.trace=context _provenance.b=call _provenance.secret=call+replace _provenance.drop=call+drop",
+		},
+		{
+			"json",
+			HandlerOptions{AttrProvenance: true, ReplaceAttr: replace},
+			true,
+			`{"level":"INFO","msg":"m","a":1,"b":2,"secret":"REDACTED",` +
+				`"_provenance":{"a":"with","b":"call","secret":"call+replace","drop":"call+drop"}}`,
+		},
+		{
+			"nested",
+			HandlerOptions{AttrProvenance: true, ReplaceAttr: replace, AutoNest: true},
+			true,
+			`{"level":"INFO","msg":"m","a":1,"b":2,"secret":"REDACTED",` +
+				`"_provenance":{"a":"with","b":"call","secret":"call+replace","drop":"call+drop"}}`,
+		},
+		{
+			"off",
+			HandlerOptions{},
+			false,
+			"level=INFO msg=m a=1 b=2 secret=s drop=d",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var h Handler
+			if test.json {
+				h = test.opts.NewJSONHandler(&buf)
+			} else {
+				h = test.opts.NewTextHandler(&buf)
+			}
+			h = h.With([]Attr{Int("a", 1)})
+			r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+			r.AddAttrs(Int("b", 2), String("secret", "s"), String("drop", "d"))
+			if err := h.Handle(r); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+				t.Errorf("\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitHandler is a Handler that limits the rate of records passed to
+// another Handler, whatever their content, by dropping the excess. It uses a
+// token bucket: each record takes a token, tokens are added at a steady rate
+// up to a maximum, the burst, and records that find no token are dropped.
+//
+// A RateLimitHandler and the handlers derived from it with With and
+// WithGroup share their bucket.
+type RateLimitHandler struct {
+	inner  Handler
+	bucket *tokenBucket
+}
+
+type tokenBucket struct {
+	rate    float64          // tokens per second
+	burst   float64          // maximum tokens
+	now     func() time.Time // for testing
+	dropped atomic.Int64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time // when tokens was computed
+}
+
+// NewRateLimitHandler creates a RateLimitHandler that passes at most burst
+// records at once, and perSecond records a second on average, to inner.
+// The bucket starts full.
+func NewRateLimitHandler(inner Handler, perSecond float64, burst int) *RateLimitHandler {
+	b := &tokenBucket{
+		rate:   perSecond,
+		burst:  float64(burst),
+		now:    time.Now,
+		tokens: float64(burst),
+	}
+	return &RateLimitHandler{inner: inner, bucket: b}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *RateLimitHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new RateLimitHandler whose inner handler has the given
+// attributes. The new handler shares its bucket with h.
+func (h *RateLimitHandler) With(attrs []Attr) Handler {
+	return &RateLimitHandler{inner: h.inner.With(attrs), bucket: h.bucket}
+}
+
+// WithGroup returns a new RateLimitHandler whose inner handler has the given
+// group. The new handler shares its bucket with h.
+func (h *RateLimitHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &RateLimitHandler{inner: h.inner.WithGroup(name), bucket: h.bucket}
+}
+
+// Handle passes r to the inner handler if a token is available, and
+// otherwise drops it and returns nil.
+func (h *RateLimitHandler) Handle(r Record) error {
+	if !h.bucket.take() {
+		h.bucket.dropped.Add(1)
+		return nil
+	}
+	return h.inner.Handle(r)
+}
+
+// Dropped returns the number of records dropped for lack of a token.
+func (h *RateLimitHandler) Dropped() int64 {
+	return h.bucket.dropped.Load()
+}
+
+// take removes a token from the bucket, if there is one, and reports
+// whether it did.
+func (b *tokenBucket) take() bool {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.last.IsZero() {
+		b.last = now
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
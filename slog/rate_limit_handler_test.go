@@ -0,0 +1,78 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewRateLimitHandler(HandlerOptions{}.NewTextHandler(&buf), 2, 3)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.bucket.now = func() time.Time { return now }
+	l := New(h).With("a", 1)
+
+	count := func() int { return strings.Count(buf.String(), "\n") }
+	logN := func(n int) {
+		for i := 0; i < n; i++ {
+			l.LogAt(time.Time{}, InfoLevel, "m")
+		}
+	}
+	logN(10)
+	if got := count(); got != 3 {
+		t.Errorf("burst: got %d records, want 3", got)
+	}
+	if got := h.Dropped(); got != 7 {
+		t.Errorf("dropped %d, want 7", got)
+	}
+	// Two tokens a second.
+	now = now.Add(time.Second)
+	logN(10)
+	if got := count(); got != 5 {
+		t.Errorf("after 1s: got %d records, want 5", got)
+	}
+	now = now.Add(time.Second / 2)
+	logN(10)
+	if got := count(); got != 6 {
+		t.Errorf("after 1.5s: got %d records, want 6", got)
+	}
+	// The bucket holds at most the burst.
+	now = now.Add(time.Hour)
+	l.WithGroup("g").LogAt(time.Time{}, InfoLevel, "m") // shares the bucket
+	logN(10)
+	if got := count(); got != 9 {
+		t.Errorf("after 1h: got %d records, want 9", got)
+	}
+	if !strings.HasSuffix(buf.String(), "level=INFO msg=m a=1\n") {
+		t.Errorf("got %q, want the With attributes", buf.String())
+	}
+}
+
+func TestRateLimitHandlerConcurrency(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewRateLimitHandler(HandlerOptions{}.NewTextHandler(&buf), 1e-9, 50)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0))
+			}
+		}()
+	}
+	wg.Wait()
+	if got := strings.Count(buf.String(), "\n"); got != 50 {
+		t.Errorf("got %d records, want 50", got)
+	}
+	if got := h.Dropped(); got != 150 {
+		t.Errorf("dropped %d, want 150", got)
+	}
+}
@@ -5,6 +5,7 @@
 package slog
 
 import (
+	"context"
 	"runtime"
 	"time"
 )
@@ -25,8 +26,12 @@ type Record struct {
 	// The level of the event.
 	level Level
 
+	// The context of the Logger that created the Record, if any.
+	ctx context.Context
+
 	// The pc at the time the record was constructed, as determined
-	// by runtime.Callers using the calldepth argument to NewRecord.
+	// by runtime.Callers using the calldepth argument to NewRecord,
+	// or as set by SetPC.
 	pc uintptr
 
 	// Allocation optimization: an inline array sized to hold
@@ -80,19 +85,46 @@ func (r *Record) Message() string { return r.message }
 // Level returns the level of the log event.
 func (r *Record) Level() Level { return r.level }
 
+// Context returns the context of the Logger that created the Record
+// (see [Logger.WithContext]), or context.Background() if there is none.
+func (r *Record) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// PC returns the program counter of the log event, or zero if it is unknown.
+func (r *Record) PC() uintptr { return r.pc }
+
+// SetPC sets the program counter of the log event, from which handlers
+// determine its source location, to pc, a value obtained from
+// runtime.Callers. Logging APIs that wrap a Logger can call it to report
+// the location of their own callers, instead of passing a calldepth to
+// NewRecord. A pc of zero makes the location unknown.
+func (r *Record) SetPC(pc uintptr) { r.pc = pc }
+
 // SourceLine returns the file and line of the log event.
 // If the Record was created without the necessary information,
 // or if the location is unavailable, it returns ("", 0).
 func (r *Record) SourceLine() (file string, line int) {
+	f := r.frame()
+	return f.File, f.Line
+}
+
+// frame returns the stack frame of the log event.
+func (r *Record) frame() runtime.Frame {
 	fs := runtime.CallersFrames([]uintptr{r.pc})
 	// TODO: error-checking?
 	f, _ := fs.Next()
-	return f.File, f.Line
+	return f
 }
 
 // Clone returns a copy of the record with no shared state.
 // The original record and the clone can both be modified
-// without interfering with each other.
+// without interfering with each other: Attrs added to either
+// one with AddAttrs do not appear in the other. Handlers that
+// retain a record after Handle returns should retain a clone.
 func (r *Record) Clone() Record {
 	c := *r
 	if len(c.back) > 0 {
@@ -102,18 +134,34 @@ func (r *Record) Clone() Record {
 	return c
 }
 
+// withoutAttrs returns a copy of r with no Attrs.
+func (r *Record) withoutAttrs() Record {
+	return Record{
+		time:    r.time,
+		message: r.message,
+		level:   r.level,
+		ctx:     r.ctx,
+		pc:      r.pc,
+	}
+}
+
 // NumAttrs returns the number of attributes in the Record.
 func (r *Record) NumAttrs() int {
 	return r.nFront + len(r.back)
 }
 
-// Attrs calls f on each Attr in the Record.
-func (r *Record) Attrs(f func(Attr)) {
+// Attrs calls f on each Attr in the Record, in the order they were added.
+// Iteration stops if f returns false.
+func (r *Record) Attrs(f func(Attr) bool) {
 	for i := 0; i < r.nFront; i++ {
-		f(r.front[i])
+		if !f(r.front[i]) {
+			return
+		}
 	}
 	for _, a := range r.back {
-		f(a)
+		if !f(a) {
+			return
+		}
 	}
 }
 
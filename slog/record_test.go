@@ -5,6 +5,9 @@
 package slog
 
 import (
+	"fmt"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -25,6 +28,34 @@ func TestRecordAttrs(t *testing.T) {
 	}
 }
 
+func TestRecordAttrsIteration(t *testing.T) {
+	r := NewRecord(time.Time{}, InfoLevel, "", 0)
+	// Enough Attrs to use both the inline array and the slice.
+	for i := 0; i < nAttrsInline+3; i++ {
+		r.AddAttrs(Int(strconv.Itoa(i), i))
+	}
+	var keys []string
+	r.Attrs(func(a Attr) bool {
+		keys = append(keys, a.Key())
+		return true
+	})
+	if got, want := strings.Join(keys, ","), "0,1,2,3,4,5,6,7"; got != want {
+		t.Errorf("got keys %s, want %s", got, want)
+	}
+
+	// Stop early, in both parts.
+	for _, n := range []int{2, nAttrsInline + 1} {
+		keys = nil
+		r.Attrs(func(a Attr) bool {
+			keys = append(keys, a.Key())
+			return len(keys) < n
+		})
+		if len(keys) != n {
+			t.Errorf("stop after %d: got keys %v", n, keys)
+		}
+	}
+}
+
 func TestRecordSourceLine(t *testing.T) {
 	// Zero call depth => empty file/line
 	for _, test := range []struct {
@@ -48,6 +79,35 @@ func TestRecordSourceLine(t *testing.T) {
 	}
 }
 
+func TestRecordSetPC(t *testing.T) {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:]) // the line whose location we want
+	_, _, wantLine, _ := runtime.Caller(0)
+	wantLine--
+
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	if r.PC() != 0 {
+		t.Fatalf("got PC %#x, want 0", r.PC())
+	}
+	r.SetPC(pcs[0])
+	if r.PC() != pcs[0] {
+		t.Errorf("got PC %#x, want %#x", r.PC(), pcs[0])
+	}
+	file, line := r.SourceLine()
+	if !strings.HasSuffix(file, "record_test.go") || line != wantLine {
+		t.Errorf("got %s:%d, want record_test.go:%d", file, line, wantLine)
+	}
+
+	// Handlers resolve the source from the PC.
+	var buf strings.Builder
+	if err := (HandlerOptions{AddSource: true}).NewTextHandler(&buf).Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("record_test.go:%d msg=m", wantLine); !strings.Contains(buf.String(), want) {
+		t.Errorf("got %q, want it to contain %q", buf.String(), want)
+	}
+}
+
 func TestAliasingAndClone(t *testing.T) {
 	intAttrs := func(from, to int) []Attr {
 		var as []Attr
@@ -89,6 +149,30 @@ func TestAliasingAndClone(t *testing.T) {
 	check(r2, append(slices.Clip(r1Attrs), Int("p", 2)))
 }
 
+func TestRecordClone(t *testing.T) {
+	// Enough Attrs to spill out of the inline array, with room to
+	// append in place.
+	as := []Attr{Int("a", 1), Int("b", 2), Int("c", 3), Int("d", 4), Int("e", 5), Int("f", 6)}
+	r := NewRecord(testTime, WarnLevel, "m", 0)
+	r.AddAttrs(as...)
+	r.back = append(make([]Attr, 0, len(r.back)+4), r.back...)
+	c := r.Clone()
+
+	r.AddAttrs(Int("g", 7), Int("h", 8))
+	if got := attrsSlice(c); !attrsEqual(got, as) {
+		t.Errorf("clone changed: got %v, want %v", got, as)
+	}
+	if !c.Time().Equal(testTime) || c.Level() != WarnLevel || c.Message() != "m" {
+		t.Errorf("got clone (%s, %s, %q), want (%s, %s, %q)",
+			c.Time(), c.Level(), c.Message(), testTime, WarnLevel, "m")
+	}
+	// The clone can be extended too, without affecting r.
+	c.AddAttrs(Int("x", 0))
+	if got, want := attrsSlice(r), append(slices.Clip(as), Int("g", 7), Int("h", 8)); !attrsEqual(got, want) {
+		t.Errorf("original: got %v, want %v", got, want)
+	}
+}
+
 func newRecordWithAttrs(as []Attr) Record {
 	r := NewRecord(time.Now(), InfoLevel, "", 0)
 	r.AddAttrs(as...)
@@ -97,7 +181,7 @@ func newRecordWithAttrs(as []Attr) Record {
 
 func attrsSlice(r Record) []Attr {
 	s := make([]Attr, 0, r.NumAttrs())
-	r.Attrs(func(a Attr) { s = append(s, a) })
+	r.Attrs(func(a Attr) bool { s = append(s, a); return true })
 	return s
 }
 
@@ -148,7 +232,7 @@ func BenchmarkRecord(b *testing.B) {
 		for j := 0; j < nAttrs; j++ {
 			r.AddAttrs(Int("k", j))
 		}
-		r.Attrs(func(b Attr) { a = b })
+		r.Attrs(func(b Attr) bool { a = b; return true })
 	}
 	_ = a
 }
@@ -0,0 +1,46 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+// A Resource describes the entity producing records, as with the resource
+// attributes of OpenTelemetry. See [HandlerOptions.Resource].
+type Resource struct {
+	// ServiceName, ServiceVersion and DeploymentEnvironment are output,
+	// if non-empty, with the keys "service.name", "service.version" and
+	// "deployment.environment" of the OpenTelemetry semantic conventions.
+	ServiceName           string
+	ServiceVersion        string
+	DeploymentEnvironment string
+
+	// Attrs are other resource attributes, output after those above.
+	Attrs []Attr
+
+	// If Flat is set, the resource attributes are output at the top level
+	// of each record. Otherwise they are output in a group with
+	// key "resource".
+	Flat bool
+}
+
+// attrs returns the Attrs to output on each record for r, which may be nil.
+func (r *Resource) attrs() []Attr {
+	if r == nil {
+		return nil
+	}
+	var as []Attr
+	for _, f := range []struct{ key, val string }{
+		{"service.name", r.ServiceName},
+		{"service.version", r.ServiceVersion},
+		{"deployment.environment", r.DeploymentEnvironment},
+	} {
+		if f.val != "" {
+			as = append(as, String(f.key, f.val))
+		}
+	}
+	as = append(as, r.Attrs...)
+	if len(as) == 0 || r.Flat {
+		return as
+	}
+	return []Attr{Group("resource", as...)}
+}
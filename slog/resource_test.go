@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerResource(t *testing.T) {
+	res := Resource{
+		ServiceName:           "checkout",
+		ServiceVersion:        "1.2.3",
+		DeploymentEnvironment: "prod",
+		Attrs:                 []Attr{String("host.name", "h1")},
+	}
+	flat := res
+	flat.Flat = true
+	for _, test := range []struct {
+		name string
+		new  func(*bytes.Buffer) Handler
+		want []string
+	}{
+		{
+			"json",
+			func(buf *bytes.Buffer) Handler {
+				return HandlerOptions{Resource: &res}.NewJSONHandler(buf)
+			},
+			[]string{
+				`{"level":"INFO","msg":"m1","resource":{"service.name":"checkout","service.version":"1.2.3","deployment.environment":"prod","host.name":"h1"},"a":1}`,
+				`{"level":"WARN","msg":"m2","resource":{"service.name":"checkout","service.version":"1.2.3","deployment.environment":"prod","host.name":"h1"},"a":1,"b":2}`,
+			},
+		},
+		{
+			"json flat",
+			func(buf *bytes.Buffer) Handler {
+				return HandlerOptions{Resource: &flat}.NewJSONHandler(buf)
+			},
+			[]string{
+				`{"level":"INFO","msg":"m1","service.name":"checkout","service.version":"1.2.3","deployment.environment":"prod","host.name":"h1","a":1}`,
+				`{"level":"WARN","msg":"m2","service.name":"checkout","service.version":"1.2.3","deployment.environment":"prod","host.name":"h1","a":1,"b":2}`,
+			},
+		},
+		{
+			"text",
+			func(buf *bytes.Buffer) Handler {
+				return HandlerOptions{Resource: &res}.NewTextHandler(buf)
+			},
+			[]string{
+				`level=INFO msg=m1 resource.service.name=checkout resource.service.version=1.2.3 resource.deployment.environment=prod resource.host.name=h1 a=1`,
+				`level=WARN msg=m2 resource.service.name=checkout resource.service.version=1.2.3 resource.deployment.environment=prod resource.host.name=h1 a=1 b=2`,
+			},
+		},
+		{
+			"text partial",
+			func(buf *bytes.Buffer) Handler {
+				return HandlerOptions{Resource: &Resource{ServiceName: "checkout", Flat: true}}.NewTextHandler(buf)
+			},
+			[]string{
+				`level=INFO msg=m1 service.name=checkout a=1`,
+				`level=WARN msg=m2 service.name=checkout a=1 b=2`,
+			},
+		},
+		{
+			"empty",
+			func(buf *bytes.Buffer) Handler {
+				return HandlerOptions{Resource: &Resource{}}.NewTextHandler(buf)
+			},
+			[]string{
+				`level=INFO msg=m1 a=1`,
+				`level=WARN msg=m2 a=1 b=2`,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := test.new(&buf).With([]Attr{Int("a", 1)})
+			r1 := NewRecord(time.Time{}, InfoLevel, "m1", 0)
+			r2 := NewRecord(time.Time{}, WarnLevel, "m2", 0)
+			r2.AddAttrs(Int("b", 2))
+			for _, r := range []Record{r1, r2} {
+				if err := h.Handle(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d lines, want %d:\n%s", len(got), len(test.want), buf.String())
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("\ngot  %s\nwant %s", got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// RetryHandler is a Handler that retries records whose handling fails
+// with a transient error.
+type RetryHandler struct {
+	inner    Handler
+	attempts int
+	backoff  time.Duration
+	mu       *sync.Mutex // serializes Handle, so records are not reordered
+}
+
+// NewRetryHandler creates a RetryHandler that passes records to inner.
+// If inner's Handle method returns a transient error, it is called again
+// after waiting for backoff. The wait doubles after each failure.
+// Handle is called at most attempts times for each record, after which the
+// last error is returned.
+//
+// An error is transient if it, or an error it wraps, has a method
+// Temporary() bool that returns true, as some net.Errors do.
+func NewRetryHandler(inner Handler, attempts int, backoff time.Duration) *RetryHandler {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &RetryHandler{
+		inner:    inner,
+		attempts: attempts,
+		backoff:  backoff,
+		mu:       &sync.Mutex{},
+	}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *RetryHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new RetryHandler whose inner handler has the given attributes.
+// The new handler is serialized with h.
+func (h *RetryHandler) With(attrs []Attr) Handler {
+	h2 := *h
+	h2.inner = h.inner.With(attrs)
+	return &h2
+}
+
+// WithGroup returns a new RetryHandler whose inner handler has the given
+// group. The new handler is serialized with h.
+func (h *RetryHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.inner = h.inner.WithGroup(name)
+	return &h2
+}
+
+// Handle passes r to the inner handler, retrying on transient errors.
+// While a record is being retried, other calls to Handle wait.
+func (h *RetryHandler) Handle(r Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	wait := h.backoff
+	var err error
+	for i := 0; i < h.attempts; i++ {
+		if i > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		err = h.inner.Handle(r)
+		if err == nil || !isTransient(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func isTransient(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary failure" }
+func (temporaryError) Temporary() bool { return true }
+
+// failingWriter fails its first n writes with err.
+type failingWriter struct {
+	n     int
+	err   error
+	calls int
+	buf   bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.calls <= w.n {
+		return 0, w.err
+	}
+	return w.buf.Write(p)
+}
+
+func TestRetryHandler(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		fails     int
+		err       error
+		attempts  int
+		wantErr   bool
+		wantCalls int
+		wantLines int
+	}{
+		{"succeeds", 2, temporaryError{}, 3, false, 3, 1},
+		{"wrapped", 2, fmt.Errorf("write: %w", temporaryError{}), 3, false, 3, 1},
+		{"exhausted", 5, temporaryError{}, 3, true, 3, 0},
+		{"permanent", 2, errors.New("permanent"), 3, true, 1, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := &failingWriter{n: test.fails, err: test.err}
+			h := NewRetryHandler(NewTextHandler(w), test.attempts, time.Millisecond)
+			err := h.Handle(NewRecord(testTime, InfoLevel, "m", 0))
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error: %t", err, test.wantErr)
+			}
+			if w.calls != test.wantCalls {
+				t.Errorf("got %d calls to Write, want %d", w.calls, test.wantCalls)
+			}
+			if got := bytes.Count(w.buf.Bytes(), []byte("\n")); got != test.wantLines {
+				t.Errorf("got %d lines, want %d", got, test.wantLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// A RotatingFileWriter is an io.Writer that writes to a file, and moves the
+// file aside and starts a new one when the file would exceed a size limit.
+//
+// Since the TextHandler and JSONHandler write each record with a single call
+// to Write, and a RotatingFileWriter rotates only between calls, no record is
+// split between files.
+type RotatingFileWriter struct {
+	path     string
+	maxBytes int64
+
+	mu   sync.Mutex
+	f    *os.File // nil if opening a new file failed
+	size int64
+
+	now func() time.Time // for testing
+}
+
+// NewRotatingFileWriter opens the file at path for appending, creating it if
+// necessary, and returns a RotatingFileWriter that writes to it.
+//
+// Before a write would make the file larger than maxBytes, the file is
+// closed and renamed by appending a dot and the current time to its path, as
+// in "app.log.20060102T150405.000000000", and a new file is created at path.
+// A write larger than maxBytes is written to a file of its own.
+func NewRotatingFileWriter(path string, maxBytes int64) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{path: path, maxBytes: maxBytes, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingFileWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f = f
+	w.size = info.Size()
+	return nil
+}
+
+// Write writes p to the current file, first rotating the file if necessary.
+// If rotating fails, Write returns the error without writing p, and later
+// calls try again.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current file aside and opens a new one.
+// If the file cannot be moved, it is opened again, so that writing can
+// continue. If no file can be opened, w.f is nil.
+// w.mu must be held.
+func (w *RotatingFileWriter) rotate() error {
+	// Close the file before renaming it, since some systems do not allow
+	// renaming open files.
+	err := w.f.Close()
+	w.f = nil
+	if err != nil {
+		return err
+	}
+	backup := w.path + "." + w.now().Format("20060102T150405.000000000")
+	if err := os.Rename(w.path, backup); err != nil {
+		if oerr := w.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+	return w.open()
+}
+
+// Close closes the current file.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewRotatingFileWriter(path, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewJSONHandler(w)
+	// Each record is 27 bytes, so two fit in a file.
+	const n = 5
+	for i := 0; i < n; i++ {
+		if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(path + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(files), 3; got != want {
+		t.Fatalf("got %d files, want %d: %v", got, want, files)
+	}
+	total := 0
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 64 {
+			t.Errorf("%s: got %d bytes, want at most 64", f, len(data))
+		}
+		for _, line := range strings.SplitAfter(string(data), "\n") {
+			if line == "" {
+				continue
+			}
+			total++
+			if want := `{"level":"INFO","msg":"m"}` + "\n"; line != want {
+				t.Errorf("%s: got line %q, want %q", f, line, want)
+			}
+		}
+	}
+	if total != n {
+		t.Errorf("got %d records, want %d", total, n)
+	}
+}
+
+func TestRotatingFileWriterFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewRotatingFileWriter(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.now = func() time.Time { return testTime }
+	backup := path + "." + testTime.Format("20060102T150405.000000000")
+	// A non-empty directory where the file would be moved makes the
+	// rename fail.
+	if err := os.MkdirAll(filepath.Join(backup, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(s string) error {
+		_, err := w.Write([]byte(s))
+		return err
+	}
+	if err := write("aaaaaaaa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := write("bbbbbbbb"); err == nil {
+		t.Fatal("got nil error, want rotation error")
+	}
+	// Writing continues once the rename is possible.
+	if err := os.RemoveAll(backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := write("cccccccc"); err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string]string{backup: "aaaaaaaa", path: "cccccccc"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); got != want {
+			t.Errorf("%s: got %q, want %q", file, got, want)
+		}
+	}
+}
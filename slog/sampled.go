@@ -0,0 +1,38 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "hash/fnv"
+
+// SampledLog is like [Logger.Log], but emits the record only for a fraction
+// of the possible values of key, such as user IDs. The decision depends only
+// on key and fraction: calls with the same key are consistently kept or
+// dropped, across records, Loggers and processes, so the records for a
+// subset of users can be followed end to end. Raising fraction keeps keys
+// that were kept before.
+//
+// A fraction of 1 or more keeps all records, and a fraction of 0 or less
+// drops them all.
+func (l *Logger) SampledLog(key string, fraction float64, level Level, msg string, args ...any) {
+	if !sampled(key, fraction) {
+		return
+	}
+	l.LogDepth(0, level, msg, args...)
+}
+
+// sampled reports whether records with the given sampling key are kept when
+// sampling the given fraction of keys.
+func sampled(key string, fraction float64) bool {
+	switch {
+	case fraction >= 1:
+		return true
+	case fraction <= 0:
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// Map the hash to [0, 1).
+	return float64(h.Sum64()>>11)/(1<<53) < fraction
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSampledLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(HandlerOptions{ReplaceAttr: func(_ []string, a Attr) Attr {
+		if a.Key() == "time" {
+			return Attr{}
+		}
+		return a
+	}}.NewTextHandler(&buf))
+
+	const (
+		nKeys    = 1000
+		fraction = 0.2
+	)
+	kept := map[string]bool{}
+	for i := 0; i < nKeys; i++ {
+		key := fmt.Sprintf("user%d", i)
+		buf.Reset()
+		l.SampledLog(key, fraction, InfoLevel, "m", "user", key)
+		kept[key] = buf.Len() > 0
+	}
+	n := 0
+	for _, k := range kept {
+		if k {
+			n++
+		}
+	}
+	if n < nKeys*fraction*0.8 || n > nKeys*fraction*1.2 {
+		t.Errorf("kept %d of %d keys, want about %d", n, nKeys, int(nKeys*fraction))
+	}
+
+	// Later records with the same keys get the same decisions, and raising
+	// the fraction keeps the keys kept before.
+	for key, want := range kept {
+		buf.Reset()
+		l.SampledLog(key, fraction, WarnLevel, "again")
+		if got := buf.Len() > 0; got != want {
+			t.Errorf("%s: kept %t, then %t", key, want, got)
+		}
+		if want && !sampled(key, 2*fraction) {
+			t.Errorf("%s: dropped at a higher fraction", key)
+		}
+	}
+
+	buf.Reset()
+	l.SampledLog("u", 1, InfoLevel, "all")
+	l.SampledLog("u", 0, InfoLevel, "none")
+	l.SampledLog("u", 1, DebugLevel, "disabled")
+	if got, want := strings.TrimSuffix(buf.String(), "\n"), "level=INFO msg=all"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
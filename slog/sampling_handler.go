@@ -0,0 +1,147 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"sync"
+	"time"
+)
+
+// SamplingOptions are options for a SamplingHandler.
+type SamplingOptions struct {
+	// First is the number of records with the same key that are handled in
+	// each window before sampling begins.
+	First int
+
+	// Thereafter is the sampling rate after the first records: one record
+	// in every Thereafter with the same key is handled, and the rest are
+	// dropped. If Thereafter is zero or negative, all of them are dropped.
+	Thereafter int
+
+	// Window is the length of the period after which the count of records
+	// with a key resets. A key's window starts with its first record.
+	// If Window is zero or negative, it is one second.
+	Window time.Duration
+
+	// KeyFunc returns the key under which a record is counted.
+	// If KeyFunc is nil, records are counted by their level and message.
+	KeyFunc func(Record) string
+}
+
+// SamplingHandler is a Handler that limits the volume of repeated records
+// before passing them to another Handler. In each window it passes on the
+// first records with a key, and then samples the rest; see SamplingOptions.
+//
+// A SamplingHandler and the handlers derived from it with With and
+// WithGroup share their counts. The counts of keys whose windows have ended
+// are deleted, so the memory used depends on the number of keys logged
+// recently, not on all the keys ever logged.
+type SamplingHandler struct {
+	inner Handler
+	state *samplingState
+}
+
+type samplingState struct {
+	opts   SamplingOptions
+	now    func() time.Time // for testing
+	mu     sync.Mutex
+	counts map[string]*sampleCount
+	swept  time.Time // when expired counts were last deleted
+}
+
+type sampleCount struct {
+	start time.Time // of the window
+	n     int       // records in the window, including the current one
+}
+
+// NewSamplingHandler creates a SamplingHandler that passes the records
+// selected by opts to inner.
+func NewSamplingHandler(inner Handler, opts SamplingOptions) *SamplingHandler {
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = levelMessageKey
+	}
+	return &SamplingHandler{
+		inner: inner,
+		state: &samplingState{opts: opts, now: time.Now, counts: map[string]*sampleCount{}},
+	}
+}
+
+func levelMessageKey(r Record) string {
+	return r.Level().String() + " " + r.Message()
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *SamplingHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new SamplingHandler whose inner handler has the given
+// attributes. The new handler shares counts with h.
+func (h *SamplingHandler) With(attrs []Attr) Handler {
+	return &SamplingHandler{inner: h.inner.With(attrs), state: h.state}
+}
+
+// WithGroup returns a new SamplingHandler whose inner handler has the given
+// group. The new handler shares counts with h.
+func (h *SamplingHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &SamplingHandler{inner: h.inner.WithGroup(name), state: h.state}
+}
+
+// Handle passes r to the inner handler if it is selected, and otherwise
+// drops it and returns nil.
+func (h *SamplingHandler) Handle(r Record) error {
+	if !h.state.keep(h.state.opts.KeyFunc(r)) {
+		return nil
+	}
+	return h.inner.Handle(r)
+}
+
+// keep counts a record with the given key, and reports whether it should be
+// handled.
+func (s *samplingState) keep(key string) bool {
+	now := s.now()
+	s.mu.Lock()
+	if now.Sub(s.swept) >= s.opts.Window {
+		s.sweep(now)
+	}
+	c := s.counts[key]
+	if c == nil || now.Sub(c.start) >= s.opts.Window {
+		if c == nil {
+			c = &sampleCount{}
+			s.counts[key] = c
+		}
+		c.start = now
+		c.n = 0
+	}
+	c.n++
+	n := c.n
+	s.mu.Unlock()
+
+	if n <= s.opts.First {
+		return true
+	}
+	m := s.opts.Thereafter
+	return m > 0 && (n-s.opts.First)%m == 0
+}
+
+// sweep deletes the counts whose windows have ended, so that the counts of
+// keys that are no longer logged do not accumulate. Since it is called at
+// most once a window, the counts held are those of the keys logged in the
+// last two windows.
+// s.mu must be held.
+func (s *samplingState) sweep(now time.Time) {
+	for key, c := range s.counts {
+		if now.Sub(c.start) >= s.opts.Window {
+			delete(s.counts, key)
+		}
+	}
+	s.swept = now
+}
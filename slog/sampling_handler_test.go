@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(HandlerOptions{}.NewTextHandler(&buf),
+		SamplingOptions{First: 3, Thereafter: 10, Window: time.Minute})
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.state.now = func() time.Time { return now }
+
+	count := func(msg string) int {
+		return strings.Count(buf.String(), "msg="+msg+"\n")
+	}
+	l := New(h)
+	// 3 records, then 1 in 10 of the remaining 97.
+	for i := 0; i < 100; i++ {
+		l.Info("a")
+		l.Warn("a")
+		l.Info("b")
+		now = now.Add(time.Second / 2)
+	}
+	if got, want := count("a"), 2*(3+9); got != want {
+		t.Errorf("a: got %d, want %d", got, want)
+	}
+	if got, want := count("b"), 3+9; got != want {
+		t.Errorf("b: got %d, want %d", got, want)
+	}
+	if got, want := strings.Count(buf.String(), "level=WARN"), 3+9; got != want {
+		t.Errorf("WARN: got %d, want %d", got, want)
+	}
+
+	// The window for "b" started 50s ago, so 10 seconds from now it resets.
+	buf.Reset()
+	now = now.Add(9 * time.Second)
+	l.Info("b")
+	if got := count("b"); got != 0 {
+		t.Errorf("before reset: got %d, want 0", got)
+	}
+	now = now.Add(time.Second)
+	for i := 0; i < 5; i++ {
+		l.Info("b")
+	}
+	if got := count("b"); got != 3 {
+		t.Errorf("after reset: got %d, want 3", got)
+	}
+}
+
+func TestSamplingHandlerEviction(t *testing.T) {
+	// The counts of keys that are no longer logged are deleted.
+	h := NewSamplingHandler(discardHandler{}, SamplingOptions{First: 1, Window: time.Minute})
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.state.now = func() time.Time { return now }
+	l := New(h)
+	for i := 0; i < 1000; i++ {
+		l.Info(fmt.Sprint("m", i))
+	}
+	if got, want := len(h.state.counts), 1000; got != want {
+		t.Fatalf("got %d counts, want %d", got, want)
+	}
+	now = now.Add(time.Minute)
+	l.Info("new")
+	if got, want := len(h.state.counts), 1; got != want {
+		t.Errorf("after a window: got %d counts, want %d", got, want)
+	}
+}
+
+func TestSamplingHandlerKeyFunc(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(HandlerOptions{}.NewTextHandler(&buf), SamplingOptions{
+		First:   1,
+		KeyFunc: func(r Record) string { return r.Level().String() },
+	})
+	l := New(h).With("a", 1)
+	l.LogAt(time.Time{}, InfoLevel, "x")
+	l.LogAt(time.Time{}, InfoLevel, "y")
+	l.WithGroup("g").LogAt(time.Time{}, WarnLevel, "z")
+	want := "level=INFO msg=x a=1\nlevel=WARN msg=z a=1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSamplingHandlerConcurrency(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(HandlerOptions{}.NewTextHandler(&buf),
+		SamplingOptions{First: 10, Thereafter: 5, Window: time.Hour})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := New(h)
+			for j := 0; j < 100; j++ {
+				l.Info("m")
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := strings.Count(buf.String(), "\n"), 10+990/5; got != want {
+		t.Errorf("got %d records, want %d", got, want)
+	}
+}
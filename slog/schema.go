@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// schemaKey is the key of the Attr that a handler outputs after an Attr
+// that does not match its registered Kind.
+const schemaKey = "!SCHEMA"
+
+// checkSchemas determines whether handlers check Attrs against the
+// registered schema. It is a variable for testing.
+var checkSchemas = strictBuild
+
+var (
+	schemaMu sync.Mutex   // serializes RegisterSchema
+	schema   atomic.Value // map[string]Kind; replaced, never modified
+)
+
+// RegisterSchema declares the Kinds of the values of Attrs with the given keys.
+// It is intended to be called from init functions. A later registration of a
+// key replaces an earlier one.
+//
+// When the program is built with the slog_strict build tag, the TextHandler
+// and JSONHandler check each Attr whose key was registered, except for the
+// built-in ones. If the Attr's Kind differs from the registered one, the
+// Attr is output followed by an Attr with key "!SCHEMA" describing the
+// mismatch. Attrs are checked as they are output, after ReplaceAttr and the
+// other options that change or omit them. Without the build tag, the schema
+// is ignored.
+func RegisterSchema(kinds map[string]Kind) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	old, _ := schema.Load().(map[string]Kind)
+	m := make(map[string]Kind, len(old)+len(kinds))
+	for k, v := range old {
+		m[k] = v
+	}
+	for k, v := range kinds {
+		m[k] = v
+	}
+	schema.Store(m)
+}
+
+// schemaMismatch returns a description of how a differs from the
+// registered schema, or the empty string if it matches.
+func schemaMismatch(a Attr) string {
+	m, _ := schema.Load().(map[string]Kind)
+	want, ok := m[a.Key()]
+	if !ok || a.Kind() == want {
+		return ""
+	}
+	return fmt.Sprintf("%s has kind %s, want %s", a.Key(), a.Kind(), want)
+}
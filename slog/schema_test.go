@@ -0,0 +1,88 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchema(t *testing.T) {
+	defer func(b bool) { checkSchemas = b }(checkSchemas)
+	checkSchemas = true
+	registerTestSchema(t, map[string]Kind{"count": Int64Kind, "name": StringKind})
+
+	for _, test := range []struct {
+		attr Attr
+		want string
+	}{
+		{Int("count", 1), "count=1"},
+		{String("name", "n"), "name=n"},
+		{Bool("other", true), "other=true"},
+		{String("count", "one"), `count=one !SCHEMA="count has kind String, want Int64"`},
+	} {
+		var buf bytes.Buffer
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(test.attr)
+		if err := NewTextHandler(&buf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		got := strings.TrimSuffix(buf.String(), "\n")
+		if want := "level=INFO msg=m " + test.want; got != want {
+			t.Errorf("\ngot  %s\nwant %s", got, want)
+		}
+	}
+}
+
+// registerTestSchema registers kinds for the duration of the test.
+func registerTestSchema(t *testing.T, kinds map[string]Kind) {
+	old, _ := schema.Load().(map[string]Kind)
+	t.Cleanup(func() { schema.Store(old) })
+	RegisterSchema(kinds)
+}
+
+func TestSchemaReplaced(t *testing.T) {
+	// The Attr is checked as it is output, after ReplaceAttr.
+	defer func(b bool) { checkSchemas = b }(checkSchemas)
+	checkSchemas = true
+	registerTestSchema(t, map[string]Kind{"a.count": Int64Kind})
+	replace := func(_ []string, a Attr) Attr {
+		switch a.Value() {
+		case "drop":
+			return Attr{}
+		case "one":
+			return Int(a.Key(), 1)
+		case "two":
+			return String(a.Key(), "2")
+		}
+		return a
+	}
+	for _, test := range []struct {
+		autoNest bool
+		value    string
+		want     string
+	}{
+		{false, "drop", ""},
+		{false, "one", `,"a.count":1`},
+		{false, "two", `,"a.count":"2","!SCHEMA":"a.count has kind String, want Int64"`},
+		{true, "drop", ""},
+		{true, "one", `,"a":{"count":1}`},
+		{true, "two", `,"a":{"count":"2"},"!SCHEMA":"a.count has kind String, want Int64"`},
+	} {
+		var buf bytes.Buffer
+		opts := HandlerOptions{ReplaceAttr: replace, AutoNest: test.autoNest}
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(String("a.count", test.value))
+		if err := opts.NewJSONHandler(&buf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		got := strings.TrimSuffix(buf.String(), "\n")
+		if want := `{"level":"INFO","msg":"m"` + test.want + "}"; got != want {
+			t.Errorf("AutoNest=%t, %s:\ngot  %s\nwant %s", test.autoNest, test.value, got, want)
+		}
+	}
+}
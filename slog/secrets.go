@@ -0,0 +1,119 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"math"
+	"regexp"
+	"strings"
+)
+
+// maskedSecret replaces secrets found by HandlerOptions.MaskSecrets.
+const maskedSecret = "[REDACTED]"
+
+var (
+	// awsKeyRE matches AWS access key IDs.
+	awsKeyRE = regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)
+	// bearerRE matches the credentials of HTTP Bearer and Basic
+	// authorization. The first submatch is an "Authorization:" prefix, if
+	// any, and the second is the credentials.
+	bearerRE = regexp.MustCompile(`(?i)(\bauthorization\s*[:=]\s*)?\b(?:bearer|basic)\s+([A-Za-z0-9._~+/=-]+)`)
+	// tokenRE matches candidate tokens for the entropy check.
+	tokenRE = regexp.MustCompile(`[A-Za-z0-9+/_-]{20,}={0,2}`)
+)
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above
+// which a token is considered random enough to be a secret. Random base64
+// has about 6 bits per character, hex at most 4, and English text much
+// less.
+const minSecretEntropy = 4.0
+
+// maskSecrets returns s with the secrets it seems to contain replaced.
+func maskSecrets(s string) string {
+	s = awsKeyRE.ReplaceAllLiteralString(s, maskedSecret)
+	s = maskAuthorization(s)
+	return tokenRE.ReplaceAllStringFunc(s, func(tok string) string {
+		if isSecretToken(tok) {
+			return maskedSecret
+		}
+		return tok
+	})
+}
+
+// maskAuthorization returns s with the credentials of Bearer and Basic
+// authorization replaced. Credentials that follow "Authorization:" are always
+// replaced; others only if they look like credentials rather than words, as
+// in "the basic idea".
+func maskAuthorization(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range bearerRE.FindAllStringSubmatchIndex(s, -1) {
+		cred := s[m[4]:m[5]]
+		if m[2] < 0 && !isCredential(cred) {
+			continue
+		}
+		b.WriteString(s[last:m[4]])
+		b.WriteString(maskedSecret)
+		last = m[5]
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isCredential reports whether tok, the word after "Bearer" or "Basic",
+// looks like credentials: it is at least 8 characters long and has a digit,
+// punctuation, or an upper-case letter after the first character, as tokens
+// and base64 do but words rarely do.
+func isCredential(tok string) bool {
+	if len(tok) < 8 {
+		return false
+	}
+	for i := 0; i < len(tok); i++ {
+		c := tok[i]
+		switch {
+		case 'a' <= c && c <= 'z':
+		case 'A' <= c && c <= 'Z':
+			if i > 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretToken reports whether tok, a run of letters, digits and base64
+// punctuation, looks like a randomly generated credential: it mixes letters
+// and digits and has high entropy.
+func isSecretToken(tok string) bool {
+	var letters, digits bool
+	var counts [256]int
+	for i := 0; i < len(tok); i++ {
+		c := tok[i]
+		counts[c]++
+		switch {
+		case '0' <= c && c <= '9':
+			digits = true
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+			letters = true
+		}
+	}
+	if !letters || !digits {
+		return false
+	}
+	var entropy float64
+	n := float64(len(tok))
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy > minSecretEntropy
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "context"
+
+// A SpanEventRecorder records events on a trace span. Implementations
+// typically adapt a span from a tracing library such as OpenTelemetry's.
+type SpanEventRecorder interface {
+	// AddEvent records an event with the given name, severity and attributes.
+	// The recorder owns the slice.
+	AddEvent(name string, level Level, attrs []Attr)
+}
+
+// SpanEventHandler is a Handler that records each Record as an event on the
+// span carried by the Record's context, and also passes the Record to
+// another Handler.
+type SpanEventHandler struct {
+	inner    Handler
+	fromCtx  func(context.Context) SpanEventRecorder
+	minLevel Level
+	attrGroups
+}
+
+// NewSpanEventHandler creates a SpanEventHandler that finds spans in contexts
+// with spanFromContext, which should return nil if the context has no span.
+// Each event is named by the record's message, has the record's level, and
+// has the handler's and the record's attributes.
+//
+// If inner is non-nil, records are passed to it as well, and it determines
+// which levels are enabled. Otherwise, records are only recorded on spans,
+// and records below InfoLevel are ignored.
+func NewSpanEventHandler(inner Handler, spanFromContext func(context.Context) SpanEventRecorder) *SpanEventHandler {
+	return &SpanEventHandler{inner: inner, fromCtx: spanFromContext, minLevel: InfoLevel}
+}
+
+// Enabled reports whether the handler handles records at level l.
+func (h *SpanEventHandler) Enabled(l Level) bool {
+	if h.inner != nil {
+		return h.inner.Enabled(l)
+	}
+	return l >= h.minLevel
+}
+
+// With returns a new SpanEventHandler whose attributes consist of h's
+// attributes followed by attrs.
+func (h *SpanEventHandler) With(attrs []Attr) Handler {
+	h2 := *h
+	h2.attrGroups = h.with(attrs)
+	if h.inner != nil {
+		h2.inner = h.inner.With(attrs)
+	}
+	return &h2
+}
+
+// WithGroup returns a new SpanEventHandler whose later attributes, whether
+// passed to With or in records, are in a group with the given name, both in
+// events and in the records passed to the inner handler.
+func (h *SpanEventHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.attrGroups = h.withGroup(name)
+	if h.inner != nil {
+		h2.inner = h.inner.WithGroup(name)
+	}
+	return &h2
+}
+
+// Handle records r on the span in its context, if there is one,
+// and then passes r to the inner handler.
+func (h *SpanEventHandler) Handle(r Record) error {
+	if span := h.fromCtx(r.Context()); span != nil {
+		attrs := make([]Attr, 0, r.NumAttrs())
+		r.Attrs(func(a Attr) bool { attrs = append(attrs, a); return true })
+		span.AddEvent(r.Message(), r.Level(), h.all(attrs))
+	}
+	if h.inner != nil {
+		return h.inner.Handle(r)
+	}
+	return nil
+}
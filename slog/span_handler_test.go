@@ -0,0 +1,69 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type spanEvent struct {
+	name  string
+	level Level
+	attrs []Attr
+}
+
+type fakeSpan struct {
+	events []spanEvent
+}
+
+func (s *fakeSpan) AddEvent(name string, level Level, attrs []Attr) {
+	s.events = append(s.events, spanEvent{name, level, attrs})
+}
+
+type spanKey struct{}
+
+func spanFromContext(ctx context.Context) SpanEventRecorder {
+	if s, ok := ctx.Value(spanKey{}).(*fakeSpan); ok {
+		return s
+	}
+	return nil
+}
+
+func TestSpanEventHandler(t *testing.T) {
+	span := &fakeSpan{}
+	ctx := context.WithValue(context.Background(), spanKey{}, span)
+	var buf bytes.Buffer
+	l := New(NewSpanEventHandler(NewTextHandler(&buf), spanFromContext)).With("a", 1)
+
+	l.WithContext(ctx).Warn("w", "b", 2)
+	l.Info("no span")
+
+	if len(span.events) != 1 {
+		t.Fatalf("got %d events, want 1", len(span.events))
+	}
+	ev := span.events[0]
+	if ev.name != "w" || ev.level != WarnLevel {
+		t.Errorf("got event (%q, %s), want (%q, %s)", ev.name, ev.level, "w", WarnLevel)
+	}
+	if want := []Attr{Int("a", 1), Int("b", 2)}; !attrsEqual(ev.attrs, want) {
+		t.Errorf("got attrs %v, want %v", ev.attrs, want)
+	}
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 2 {
+		t.Errorf("got %d lines written, want 2", got)
+	}
+}
+
+func TestSpanEventHandlerNoInner(t *testing.T) {
+	span := &fakeSpan{}
+	ctx := context.WithValue(context.Background(), spanKey{}, span)
+	l := New(NewSpanEventHandler(nil, spanFromContext)).WithContext(ctx)
+	l.Debug("d")
+	l.Info("i")
+	if len(span.events) != 1 || span.events[0].name != "i" {
+		t.Errorf("got %v, want one event named i", span.events)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import "runtime"
+
+// goroutineStackKey is the key of the Attr holding a goroutine's stack.
+const goroutineStackKey = "goroutine_stack"
+
+// GoroutineStackHandler is a Handler that adds the full stack of the calling
+// goroutine to records at or above a given level, as an Attr with key
+// "goroutine_stack", and passes them to another Handler. The stack is in the
+// format of runtime.Stack, and is helpful for diagnosing deadlocks.
+//
+// Capturing a stack is expensive, so the level should be one at which
+// records are rare. The stack is that of the goroutine that calls Handle,
+// so a GoroutineStackHandler should be called directly by a Logger, not
+// after records have been passed to another goroutine.
+type GoroutineStackHandler struct {
+	inner Handler
+	level Leveler
+}
+
+// NewGoroutineStackHandler creates a GoroutineStackHandler that adds stacks
+// to records whose level is at least level.Level(), and passes records to
+// inner. If level is nil, ErrorLevel is used.
+func NewGoroutineStackHandler(inner Handler, level Leveler) *GoroutineStackHandler {
+	if level == nil {
+		level = ErrorLevel
+	}
+	return &GoroutineStackHandler{inner: inner, level: level}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *GoroutineStackHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new GoroutineStackHandler whose inner handler has the
+// given attributes.
+func (h *GoroutineStackHandler) With(attrs []Attr) Handler {
+	return &GoroutineStackHandler{inner: h.inner.With(attrs), level: h.level}
+}
+
+// WithGroup returns a new GoroutineStackHandler whose inner handler has the
+// given group.
+func (h *GoroutineStackHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &GoroutineStackHandler{inner: h.inner.WithGroup(name), level: h.level}
+}
+
+// Handle adds the current goroutine's stack to r if its level is high
+// enough, and passes r to the inner handler.
+func (h *GoroutineStackHandler) Handle(r Record) error {
+	if r.Level() >= h.level.Level() {
+		r = r.Clone()
+		r.AddAttrs(String(goroutineStackKey, goroutineStack()))
+	}
+	return h.inner.Handle(r)
+}
+
+// goroutineStack returns the stack of the calling goroutine.
+func goroutineStack() string {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
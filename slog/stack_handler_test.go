@@ -0,0 +1,51 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoroutineStackHandler(t *testing.T) {
+	ch := make(chan Record, 2)
+	l := New(NewGoroutineStackHandler(NewChannelHandler(ch, false), ErrorLevel))
+
+	l.Info("m")
+	if r := <-ch; r.NumAttrs() != 0 {
+		t.Errorf("info record has %d attrs, want 0", r.NumAttrs())
+	}
+
+	l.Error("m", nil)
+	r := <-ch
+	var stack Attr
+	r.Attrs(func(a Attr) bool {
+		if a.Key() == goroutineStackKey {
+			stack = a
+			return false
+		}
+		return true
+	})
+	if stack.Kind() != StringKind {
+		t.Fatalf("got %v, want string attr %q", stack, goroutineStackKey)
+	}
+	if s := stack.String(); !strings.HasPrefix(s, "goroutine ") || !strings.Contains(s, "TestGoroutineStackHandler") {
+		t.Errorf("stack does not mention the test function:\n%s", s)
+	}
+}
+
+func TestGoroutineStackHandlerNilLevel(t *testing.T) {
+	// A nil level means ErrorLevel.
+	ch := make(chan Record, 2)
+	l := New(NewGoroutineStackHandler(NewChannelHandler(ch, false), nil))
+	l.Warn("m")
+	if r := <-ch; r.NumAttrs() != 0 {
+		t.Errorf("warn record has %d attrs, want 0", r.NumAttrs())
+	}
+	l.Error("m", nil)
+	if r := <-ch; r.NumAttrs() != 1 {
+		t.Errorf("error record has %d attrs, want 1", r.NumAttrs())
+	}
+}
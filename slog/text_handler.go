@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -30,12 +31,28 @@ func NewTextHandler(w io.Writer) *TextHandler {
 
 // NewTextHandler creates a TextHandler with the given options that writes to w.
 func (opts HandlerOptions) NewTextHandler(w io.Writer) *TextHandler {
+	interns := opts.newInternTable()
+	sep := opts.FieldSeparator
+	if sep == 0 {
+		sep = ' '
+	}
+	app := textAppender{
+		singleQuote: opts.QuoteChar == '\'',
+		interns:     interns,
+	}
+	if sep >= utf8.RuneSelf || !needsQuotingSet[sep] {
+		app.sep = sep
+	}
 	return &TextHandler{
 		&commonHandler{
-			app:     textAppender{},
-			attrSep: ' ',
-			w:       w,
-			opts:    opts,
+			app:      app,
+			interns:  interns,
+			attrSep:  sep,
+			bufs:     opts.newBufferPool(),
+			w:        opts.newWriter(w),
+			opts:     opts,
+			runID:    opts.newRunID(),
+			resource: opts.Resource.attrs(),
 		},
 	}
 }
@@ -46,6 +63,23 @@ func (h *TextHandler) With(attrs []Attr) Handler {
 	return &TextHandler{commonHandler: h.commonHandler.with(attrs)}
 }
 
+// WithGroup returns a new TextHandler whose later attributes, whether
+// passed to With or in records, have their keys qualified by name and a dot,
+// as in "g.a=1".
+func (h *TextHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &TextHandler{commonHandler: h.commonHandler.withGroup(name)}
+}
+
+// WithWriter returns a new TextHandler that is like h, with the same options and
+// attributes, but writes to w. It is useful for redirecting output, as when
+// reopening a log file after rotation.
+func (h *TextHandler) WithWriter(w io.Writer) Handler {
+	return &TextHandler{commonHandler: h.commonHandler.withWriter(w)}
+}
+
 // Handle formats its argument Record as a single line of space-separated
 // key=value items.
 //
@@ -53,12 +87,13 @@ func (h *TextHandler) With(attrs []Attr) Handler {
 // Otherwise, the key is "time"
 // and the value is output in RFC3339 format with millisecond precision.
 //
-// If the Record's level is zero, the level is omitted.
-// Otherwise, the key is "level"
+// The level's key is "level"
 // and the value of [Level.String] is output.
+// The zero level is [InfoLevel], so it is output as "INFO".
 //
 // If the AddSource option is set and source information is available,
-// the key is "source" and the value is output as FILE:LINE.
+// the key is "source" and the value is output as FILE:LINE,
+// or as a group if the SourceAsGroup option is set.
 //
 // The message's key "msg".
 //
@@ -67,6 +102,13 @@ func (h *TextHandler) With(attrs []Attr) Handler {
 //
 // If a value implements [encoding.TextMarshaler], the result of MarshalText is
 // written. Otherwise, the result of fmt.Sprint is written.
+// Nil values, including nil pointers, maps, slices, channels and functions,
+// are written as <nil>.
+// Arrays of 16 bytes that do not marshal themselves are written as UUIDs,
+// as with [UUID].
+//
+// The Attrs of a group are written with their keys qualified by the group's
+// key and a dot, as in "g.a=1". Empty groups are omitted.
 //
 // Keys and values are quoted if they contain Unicode space characters,
 // non-printing characters, '"' or '='.
@@ -77,23 +119,69 @@ func (h *TextHandler) Handle(r Record) error {
 	return h.commonHandler.handle(r)
 }
 
-type textAppender struct{}
+type textAppender struct {
+	singleQuote bool // quote with '\'' instead of '"'
+	sep         byte // separator between attrs, if not a space character
+	interns     *internTable
+}
 
 func (textAppender) appendStart(*buffer.Buffer) {}
 
 func (textAppender) appendEnd(*buffer.Buffer) {}
 
+// Groups are output as key prefixes, by the handleState.
+func (textAppender) nestsGroups() bool { return false }
+
+func (textAppender) appendOpenGroup(*buffer.Buffer, string) {}
+
+func (textAppender) appendCloseGroup(*buffer.Buffer) {}
+
 func (a textAppender) appendKey(buf *buffer.Buffer, key string) {
 	a.appendString(buf, key)
 	buf.WriteByte('=')
 }
 
-func (textAppender) appendString(buf *buffer.Buffer, s string) {
-	if needsQuoting(s) {
-		*buf = strconv.AppendQuote(*buf, s)
-	} else {
+func (a textAppender) appendString(buf *buffer.Buffer, s string) {
+	switch {
+	case !a.needsQuoting(s):
 		buf.WriteString(s)
+	case a.singleQuote:
+		*buf = appendSingleQuoted(*buf, s)
+	default:
+		*buf = strconv.AppendQuote(*buf, s)
+	}
+}
+
+// needsQuoting reports whether s must be quoted: if it needs quoting in
+// general, or if it contains the quote character or the separator.
+func (a textAppender) needsQuoting(s string) bool {
+	return needsQuoting(s) ||
+		(a.singleQuote && strings.IndexByte(s, '\'') >= 0) ||
+		(a.sep != 0 && strings.IndexByte(s, a.sep) >= 0)
+}
+
+// appendSingleQuoted appends s to buf quoted as with strconv.Quote
+// but using single quotes.
+func appendSingleQuoted(buf []byte, s string) []byte {
+	q := strconv.Quote(s)
+	q = q[1 : len(q)-1]
+	buf = append(buf, '\'')
+	for i := 0; i < len(q); i++ {
+		switch c := q[i]; c {
+		case '\\':
+			// Keep escape sequences intact, except for escaped double quotes.
+			i++
+			if q[i] != '"' {
+				buf = append(buf, c)
+			}
+			buf = append(buf, q[i])
+		case '\'':
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
 	}
+	return append(buf, '\'')
 }
 
 func (textAppender) appendTime(buf *buffer.Buffer, t time.Time) error {
@@ -102,7 +190,7 @@ func (textAppender) appendTime(buf *buffer.Buffer, t time.Time) error {
 }
 
 func (a textAppender) appendSource(buf *buffer.Buffer, file string, line int) {
-	if needsQuoting(file) {
+	if a.needsQuoting(file) {
 		a.appendString(buf, file+":"+strconv.Itoa(line))
 	} else {
 		// common case: no quoting needed.
@@ -118,13 +206,21 @@ func (app textAppender) appendAttrValue(buf *buffer.Buffer, a Attr) error {
 	case TimeKind:
 		_ = app.appendTime(buf, a.Time())
 	case AnyKind:
+		if isNil(a.any) {
+			buf.WriteString("<nil>")
+			return nil
+		}
+		if u, ok := uuidValue(a.any); ok {
+			*buf = appendUUID(*buf, u)
+			return nil
+		}
 		if tm, ok := a.any.(encoding.TextMarshaler); ok {
 			data, err := tm.MarshalText()
 			if err != nil {
 				return err
 			}
 			// TODO: avoid the conversion to string.
-			app.appendString(buf, string(data))
+			app.appendString(buf, app.interns.bytes(data))
 			return nil
 		}
 		app.appendString(buf, fmt.Sprint(a.Value()))
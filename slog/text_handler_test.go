@@ -50,6 +50,11 @@ func TestTextHandler(t *testing.T) {
 			Any("t", text{""}),
 			`t`, `"!ERROR:text: empty string"`,
 		},
+		{
+			"group",
+			Group("m", String("b", "c d")),
+			"m.b", `"c d"`,
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			for _, opts := range []struct {
@@ -203,3 +208,208 @@ func TestNeedsQuoting(t *testing.T) {
 		}
 	}
 }
+
+func TestTextHandlerSourceTrimPrefix(t *testing.T) {
+	r := NewRecord(testTime, InfoLevel, "m", 2)
+	file, line := r.SourceLine()
+	// runtime file names always use forward slashes.
+	dir := file[:strings.LastIndexByte(file, '/')+1]
+	for _, test := range []struct {
+		prefix string
+		want   string
+	}{
+		{"", fmt.Sprintf("source=%s:%d", file, line)},
+		{dir, fmt.Sprintf("source=text_handler_test.go:%d", line)},
+		{"/no/such/dir/", fmt.Sprintf("source=%s:%d", file, line)},
+	} {
+		var buf bytes.Buffer
+		h := HandlerOptions{AddSource: true, SourceTrimPrefix: test.prefix}.NewTextHandler(&buf)
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); !strings.Contains(got, test.want+" msg=m") {
+			t.Errorf("prefix %q:\ngot  %s\nwant %s", test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestTextAppendAttrValueNil(t *testing.T) {
+	for _, value := range []any{
+		nil,
+		(*int)(nil),
+		(*text)(nil),
+		map[string]int(nil),
+		[]int(nil),
+		error(nil),
+	} {
+		var buf []byte
+		if err := (textAppender{}).appendAttrValue((*buffer.Buffer)(&buf), Any("", value)); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(buf), "<nil>"; got != want {
+			t.Errorf("%T: got %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestTextHandlerLineColor(t *testing.T) {
+	for _, test := range []struct {
+		opts  HandlerOptions
+		level Level
+		want  string
+	}{
+		{HandlerOptions{}, ErrorLevel, "level=ERROR msg=m\n"},
+		{HandlerOptions{LineColor: true}, ErrorLevel, "\x1b[31mlevel=ERROR msg=m\x1b[0m\n"},
+		{HandlerOptions{LineColor: true}, ErrorLevel + 2, "\x1b[31mlevel=ERROR+2 msg=m\x1b[0m\n"},
+		{HandlerOptions{LineColor: true}, WarnLevel, "\x1b[33mlevel=WARN msg=m\x1b[0m\n"},
+		{HandlerOptions{LineColor: true}, InfoLevel, "level=INFO msg=m\n"},
+		{
+			HandlerOptions{LineColor: true, LineColors: map[Level]string{InfoLevel: "\x1b[32m"}},
+			WarnLevel,
+			"\x1b[32mlevel=WARN msg=m\x1b[0m\n",
+		},
+	} {
+		var buf bytes.Buffer
+		h := test.opts.NewTextHandler(&buf)
+		if err := h.Handle(NewRecord(time.Time{}, test.level, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.level, got, test.want)
+		}
+	}
+}
+
+func TestTextHandlerSingleQuote(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"abc", "abc"},
+		{"a b", `'a b'`},
+		{"it's", `'it\'s'`},
+		{`say "hi"`, `'say "hi"'`},
+		{`back\slash "q"`, `'back\\slash "q"'`},
+		{"tab\there", `'tab\there'`},
+	} {
+		var buf []byte
+		(textAppender{singleQuote: true}).appendString((*buffer.Buffer)(&buf), test.in)
+		if got := string(buf); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.in, got, test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	h := HandlerOptions{QuoteChar: '\''}.NewTextHandler(&buf)
+	r := NewRecord(time.Time{}, InfoLevel, "it's here", 0)
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `level=INFO msg='it\'s here'`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestTextHandlerShortLevel(t *testing.T) {
+	for _, rep := range []func([]string, Attr) Attr{nil, upperCaseKey} {
+		var buf bytes.Buffer
+		h := HandlerOptions{ShortLevel: true, ReplaceAttr: rep}.NewTextHandler(&buf)
+		if err := h.Handle(NewRecord(time.Time{}, WarnLevel, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+		got := strings.ToLower(strings.TrimSuffix(buf.String(), "\n"))
+		if want := "level=w msg=m"; got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestTextHandlerGroup(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewTextHandler(&buf).With([]Attr{Group("w", Int("x", 0))})
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	r.AddAttrs(Group("g", Int("a", 1), Group("h", String("b", "c d")), Group("empty")), Int("d", 2))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `level=INFO msg=m w.x=0 g.a=1 g.h.b="c d" d=2` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestTextHandlerLevelIcons(t *testing.T) {
+	icons := map[Level]string{InfoLevel: "ℹ️", WarnLevel: "⚠️", ErrorLevel: "❌"}
+	for _, test := range []struct {
+		level Level
+		want  string
+	}{
+		{DebugLevel, "level=DEBUG msg=m"},
+		{InfoLevel, "ℹ️ level=INFO msg=m"},
+		{WarnLevel, "⚠️ level=WARN msg=m"},
+		{WarnLevel + 1, "⚠️ level=WARN+1 msg=m"},
+		{ErrorLevel, "❌ level=ERROR msg=m"},
+	} {
+		var buf bytes.Buffer
+		h := HandlerOptions{LevelIcons: icons}.NewTextHandler(&buf)
+		if err := h.Handle(NewRecord(time.Time{}, test.level, "m", 0)); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.level, got, test.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	h := HandlerOptions{LevelIcons: icons}.NewJSONHandler(&buf)
+	if err := h.Handle(NewRecord(time.Time{}, WarnLevel, "m", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `{"level":"WARN","msg":"m"}`+"\n"; got != want {
+		t.Errorf("JSON: got %s, want %s", got, want)
+	}
+}
+
+func TestTextHandlerFieldSeparator(t *testing.T) {
+	for _, test := range []struct {
+		sep  byte
+		want string
+	}{
+		{0, `level=INFO msg=m a=1 b="x y" c=p|q`},
+		{'\t', "level=INFO\tmsg=m\ta=1\tb=\"x y\"\tc=p|q"},
+		{'|', `level=INFO|msg=m|a=1|b="x y"|c="p|q"`},
+	} {
+		var buf bytes.Buffer
+		h := HandlerOptions{FieldSeparator: test.sep}.NewTextHandler(&buf)
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(Int("a", 1), String("b", "x y"), String("c", "p|q"))
+		if err := h.Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.sep, got, test.want)
+		}
+	}
+}
+
+func TestTextHandlerWithWriter(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	h := HandlerOptions{ReplaceAttr: upperCaseKey}.NewTextHandler(&buf1).With([]Attr{Int("a", 1)})
+	h2 := h.(*TextHandler).WithWriter(&buf2).With([]Attr{Int("b", 2)})
+	r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+	if err := h2.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf2.String(), "LEVEL=INFO MSG=m A=1 B=2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if buf1.Len() != 0 {
+		t.Errorf("original writer got %q", buf1.String())
+	}
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf1.String(), "LEVEL=INFO MSG=m A=1\n"; got != want {
+		t.Errorf("original handler: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// TimingHandler is a Handler that measures how long another Handler takes
+// to handle each Record. It helps reveal when logging itself is a
+// bottleneck, as with a slow writer.
+//
+// A TimingHandler and the handlers derived from it with With share their
+// statistics.
+type TimingHandler struct {
+	inner Handler
+	stats *timingStats
+}
+
+// timingBuckets is the number of buckets in a latency histogram.
+// Bucket i holds latencies up to 2^i microseconds, except for the last,
+// which holds all larger ones.
+const timingBuckets = 24
+
+type timingStats struct {
+	mu      sync.Mutex
+	count   int64
+	total   time.Duration
+	max     time.Duration
+	buckets [timingBuckets]int64
+}
+
+// TimingStats are the latency statistics of a TimingHandler.
+type TimingStats struct {
+	Count int64         // number of calls to Handle
+	Total time.Duration // sum of the latencies of all calls
+	Max   time.Duration // greatest latency
+
+	// Buckets is a histogram of latencies. Each bucket counts the calls whose
+	// latency was greater than the previous bucket's bound, and less than or
+	// equal to its own. The bounds are powers of two microseconds, from 1µs
+	// to about 4s; the last bucket is unbounded.
+	Buckets []TimingBucket
+}
+
+// A TimingBucket is a bucket of a latency histogram.
+type TimingBucket struct {
+	Bound time.Duration // inclusive upper bound
+	Count int64
+}
+
+// Mean returns the mean latency, or zero if there have been no calls.
+func (s TimingStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// NewTimingHandler creates a TimingHandler that passes records to inner.
+func NewTimingHandler(inner Handler) *TimingHandler {
+	return &TimingHandler{inner: inner, stats: &timingStats{}}
+}
+
+// Enabled reports whether the inner handler is enabled at l.
+func (h *TimingHandler) Enabled(l Level) bool {
+	return h.inner.Enabled(l)
+}
+
+// With returns a new TimingHandler whose inner handler has the given
+// attributes. The new handler shares statistics with h.
+func (h *TimingHandler) With(attrs []Attr) Handler {
+	return &TimingHandler{inner: h.inner.With(attrs), stats: h.stats}
+}
+
+// WithGroup returns a new TimingHandler whose inner handler has the given
+// group. The new handler shares statistics with h.
+func (h *TimingHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return &TimingHandler{inner: h.inner.WithGroup(name), stats: h.stats}
+}
+
+// Handle passes r to the inner handler and records how long it took.
+func (h *TimingHandler) Handle(r Record) error {
+	start := time.Now()
+	err := h.inner.Handle(r)
+	h.stats.record(time.Since(start))
+	return err
+}
+
+// Stats returns a snapshot of the latency statistics.
+func (h *TimingHandler) Stats() TimingStats {
+	s := h.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts := TimingStats{
+		Count:   s.count,
+		Total:   s.total,
+		Max:     s.max,
+		Buckets: make([]TimingBucket, timingBuckets),
+	}
+	for i, n := range s.buckets {
+		ts.Buckets[i] = TimingBucket{Bound: timingBound(i), Count: n}
+	}
+	return ts
+}
+
+// timingBound returns the upper bound of bucket i.
+func timingBound(i int) time.Duration {
+	if i == timingBuckets-1 {
+		return math.MaxInt64
+	}
+	return time.Microsecond << i
+}
+
+func (s *timingStats) record(d time.Duration) {
+	i := 0
+	for i < timingBuckets-1 && d > timingBound(i) {
+		i++
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	s.total += d
+	if d > s.max {
+		s.max = d
+	}
+	s.buckets[i]++
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowHandler is a Handler that takes at least d to handle a record.
+type slowHandler struct {
+	discardHandler
+	d time.Duration
+}
+
+func (h slowHandler) Handle(Record) error {
+	time.Sleep(h.d)
+	return nil
+}
+
+func (h slowHandler) With([]Attr) Handler      { return h }
+func (h slowHandler) WithGroup(string) Handler { return h }
+
+func TestTimingHandler(t *testing.T) {
+	const d = 2 * time.Millisecond
+	h := NewTimingHandler(slowHandler{d: d})
+	h2 := h.With([]Attr{Int("a", 1)})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(h Handler) {
+			defer wg.Done()
+			if err := h.Handle(NewRecord(time.Time{}, InfoLevel, "m", 0)); err != nil {
+				t.Error(err)
+			}
+		}([]Handler{h, h2}[i%2])
+	}
+	wg.Wait()
+
+	s := h.Stats()
+	if s.Count != 4 {
+		t.Errorf("got count %d, want 4", s.Count)
+	}
+	if s.Max < d || s.Mean() < d || s.Total < 4*d {
+		t.Errorf("got max %s, mean %s, total %s; want at least %s each", s.Max, s.Mean(), s.Total, d)
+	}
+	var n int64
+	for i, b := range s.Buckets {
+		if b.Count > 0 && b.Bound < d {
+			t.Errorf("bucket %d with bound %s has %d calls", i, b.Bound, b.Count)
+		}
+		n += b.Count
+	}
+	if n != s.Count {
+		t.Errorf("buckets hold %d calls, want %d", n, s.Count)
+	}
+}
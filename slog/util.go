@@ -4,6 +4,8 @@
 
 package slog
 
+import "reflect"
+
 // concat returns a new slice with the elements of s1 followed
 // by those of s2. The slice has no additional capacity.
 func concat[T any](s1, s2 []T) []T {
@@ -30,3 +32,17 @@ func itoa(buf *[]byte, i int, wid int) {
 	b[bp] = byte('0' + i)
 	*buf = append(*buf, b[bp:]...)
 }
+
+// isNil reports whether v is nil or a nil value of a pointer, map, slice,
+// channel, function or interface type.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
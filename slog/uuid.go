@@ -0,0 +1,62 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+// UUID returns an Attr for a UUID, which handlers output in the canonical
+// form of RFC 4122, like "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func UUID(key string, b [16]byte) Attr {
+	return Any(key, uuid(b))
+}
+
+// uuid is the value of an Attr created by UUID.
+type uuid [16]byte
+
+func (u uuid) MarshalText() ([]byte, error) {
+	return appendUUID(make([]byte, 0, 36), u), nil
+}
+
+func (u uuid) String() string {
+	return string(appendUUID(make([]byte, 0, 36), u))
+}
+
+// appendUUID appends the canonical form of u to dst.
+func appendUUID(dst []byte, u [16]byte) []byte {
+	for i, c := range u {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			dst = append(dst, '-')
+		}
+		dst = append(dst, hex[c>>4], hex[c&0xF])
+	}
+	return dst
+}
+
+// uuidValue reports whether v, the value of an Attr of kind AnyKind, should
+// be output as a UUID, and if so returns its bytes. That is the case for
+// arrays of 16 bytes, of any type, unless they marshal themselves to JSON or
+// text; types of UUID libraries that only implement fmt.Stringer are output
+// in canonical form, regardless of their String method.
+func uuidValue(v any) ([16]byte, bool) {
+	switch v := v.(type) {
+	case [16]byte:
+		return v, true
+	case uuid:
+		return v, true
+	case json.Marshaler, encoding.TextMarshaler:
+		return [16]byte{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Array || rv.Len() != 16 || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return [16]byte{}, false
+	}
+	var b [16]byte
+	reflect.Copy(reflect.ValueOf(&b).Elem(), rv)
+	return b, true
+}
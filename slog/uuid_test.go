@@ -0,0 +1,57 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// stringerUUID is like the UUID type of a library that implements only
+// fmt.Stringer.
+type stringerUUID [16]byte
+
+func (u stringerUUID) String() string { return "custom" }
+
+// marshalingUUID is like the UUID type of a library that marshals itself.
+type marshalingUUID [16]byte
+
+func (u marshalingUUID) MarshalText() ([]byte, error) { return []byte("marshaled"), nil }
+
+func TestUUID(t *testing.T) {
+	b := [16]byte{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}
+	const want = "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"
+	if got := UUID("id", b).String(); got != want {
+		t.Errorf("String: got %q, want %q", got, want)
+	}
+	for _, test := range []struct {
+		a    Attr
+		want string
+	}{
+		{UUID("id", b), want},
+		{Any("id", b), want},
+		{Any("id", stringerUUID(b)), want},
+		{Any("id", marshalingUUID(b)), "marshaled"},
+	} {
+		r := NewRecord(time.Time{}, InfoLevel, "m", 0)
+		r.AddAttrs(test.a)
+		var buf bytes.Buffer
+		if err := NewJSONHandler(&buf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), fmt.Sprintf(`{"level":"INFO","msg":"m","id":%q}`+"\n", test.want); got != want {
+			t.Errorf("JSON: got %s, want %s", got, want)
+		}
+		buf.Reset()
+		if err := NewTextHandler(&buf).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := buf.String(), "level=INFO msg=m id="+test.want+"\n"; got != want {
+			t.Errorf("text: got %s, want %s", got, want)
+		}
+	}
+}